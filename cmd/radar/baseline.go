@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Elite-Security-Systems/radar/internal/analyzer"
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/Elite-Security-Systems/radar/internal/utils"
+)
+
+// runBaseline writes a baseline approving everything detected in a
+// known-good scan, either from saved results or from a fresh scan
+func runBaseline(args []string) int {
+	var (
+		fromPath          string
+		domainName        string
+		targetListFile    string
+		outputPath        string
+		signaturesPath    string
+		categoriesPath    string
+		includeCategories string
+		excludeCategories string
+		timeout           int
+		maxRecords        int
+		minConfidence     int
+		registrable       bool
+		silentMode        bool
+		verboseOutput     bool
+		debugMode         bool
+	)
+
+	flags := flag.NewFlagSet("baseline", flag.ExitOnError)
+	flags.StringVar(&fromPath, "from", "", "Saved radar results (file or directory) to build the baseline from instead of scanning")
+	flags.StringVar(&domainName, "domain", "", "Domain name to scan")
+	flags.StringVar(&targetListFile, "l", "", "File containing list of domains to scan (one per line)")
+	flags.StringVar(&outputPath, "o", "", "Baseline file to write (default: stdout)")
+	flags.StringVar(&signaturesPath, "signatures", "data/signatures.json", "Path to signatures file")
+	flags.StringVar(&categoriesPath, "categories", "data/categories.json", "Path to the category taxonomy file")
+	flags.StringVar(&includeCategories, "include-category", "", "Comma-separated categories (IDs, names or aliases) to detect; subcategories are included")
+	flags.StringVar(&excludeCategories, "exclude-category", "", "Comma-separated categories (IDs, names or aliases) to leave out; subcategories are excluded too")
+	flags.IntVar(&timeout, "timeout", 15, "Query timeout in seconds")
+	flags.IntVar(&maxRecords, "max-records", 1000, "Maximum number of records to collect (prevents hangs)")
+	flags.IntVar(&minConfidence, "min-confidence", 0, "Only approve detections with at least this confidence (0-100)")
+	flags.BoolVar(&registrable, "apex", false, "Reduce each input to its registrable domain (e.g. www.example.co.uk -> example.co.uk)")
+	flags.BoolVar(&silentMode, "silent", false, "Silent mode - suppress all non-error output")
+	flags.BoolVar(&verboseOutput, "verbose", false, "Show progress information when scanning")
+	flags.BoolVar(&debugMode, "debug", false, "Enable debug output")
+	flags.Parse(args)
+
+	var results []models.Result
+	if fromPath != "" {
+		loaded, err := utils.LoadResults(fromPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading results: %v\n", err)
+			return 1
+		}
+		for _, result := range loaded {
+			result.DetectedTechnologies = analyzer.FilterByConfidence(result.DetectedTechnologies, minConfidence)
+			results = append(results, result)
+		}
+	} else {
+		var domains []string
+		switch {
+		case targetListFile != "":
+			list, err := readTargetList(targetListFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			domains = list
+		case domainName != "":
+			domains = []string{domainName}
+		default:
+			fmt.Fprintln(os.Stderr, "Error: Please provide saved results with -from, a domain name with -domain or a target list with -l")
+			flags.Usage()
+			return 1
+		}
+
+		matcher, err := loadMatcher(signaturesPath, categoriesPath, includeCategories, excludeCategories, debugMode, silentMode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		config := analyzer.Config{
+			Timeout:           time.Duration(timeout) * time.Second,
+			Debug:             debugMode && !silentMode,
+			MaxRecords:        maxRecords,
+			RegistrableDomain: registrable,
+			MinConfidence:     minConfidence,
+		}
+		for i, domain := range domains {
+			if verboseOutput && !silentMode {
+				fmt.Fprintf(os.Stderr, "Processing domain %d/%d: %s\n", i+1, len(domains), domain)
+			}
+			config.Domain = domain
+			result, err := analyzer.AnalyzeDomain(config, matcher)
+			if err != nil {
+				// A baseline missing a domain would flag everything on it
+				fmt.Fprintf(os.Stderr, "Error analyzing domain %s: %v\n", domain, err)
+				return 1
+			}
+			results = append(results, *result)
+		}
+	}
+
+	baseline := analyzer.GenerateBaseline(results)
+	output, err := utils.FormatJSON(baseline)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating baseline: %v\n", err)
+		return 1
+	}
+
+	if outputPath == "" {
+		fmt.Println(output)
+		return 0
+	}
+	if err := utils.SaveToFile(output, outputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving baseline: %v\n", err)
+		return 1
+	}
+	if !silentMode {
+		fmt.Fprintf(os.Stderr, "Baseline for %d domains saved to: %s\n", len(baseline.Domains), outputPath)
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Elite-Security-Systems/radar/internal/analyzer"
+	"github.com/Elite-Security-Systems/radar/internal/policy"
+	"github.com/Elite-Security-Systems/radar/internal/utils"
+)
+
+// checkedDomain is the policy outcome for one domain in the JSON report
+type checkedDomain struct {
+	Domain     string             `json:"domain"`
+	Passed     bool               `json:"passed"`
+	Error      string             `json:"error,omitempty"`
+	Violations []policy.Violation `json:"violations,omitempty"`
+}
+
+// runCheck scans domains and evaluates a policy against every result. It
+// returns 0 when every domain passes, 1 on violations or failed scans and 2
+// on usage or setup errors, such as signatures or a baseline that fail to load.
+func runCheck(args []string) int {
+	var (
+		policyPath        string
+		domainName        string
+		targetListFile    string
+		signaturesPath    string
+		categoriesPath    string
+		includeCategories string
+		excludeCategories string
+		rulesPath         string
+		baselinePath      string
+		timeout           int
+		maxRecords        int
+		registrable       bool
+		auditDelegation   bool
+		fetchMTASTS       bool
+		jsonOutput        bool
+		silentMode        bool
+		verboseOutput     bool
+		debugMode         bool
+	)
+
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.StringVar(&policyPath, "policy", "", "Policy file (YAML or JSON) with the assertions to check")
+	flags.StringVar(&domainName, "domain", "", "Domain name to check")
+	flags.StringVar(&targetListFile, "l", "", "File containing list of domains to check (one per line)")
+	flags.StringVar(&signaturesPath, "signatures", "data/signatures.json", "Path to signatures file")
+	flags.StringVar(&categoriesPath, "categories", "data/categories.json", "Path to the category taxonomy file")
+	flags.StringVar(&includeCategories, "include-category", "", "Comma-separated categories (IDs, names or aliases) to detect; subcategories are included")
+	flags.StringVar(&excludeCategories, "exclude-category", "", "Comma-separated categories (IDs, names or aliases) to leave out; subcategories are excluded too")
+	flags.StringVar(&rulesPath, "rules", "data/rules.json", "Path to the misconfiguration rules file (empty to skip the checks); on by default so policies can assert on findings")
+	flags.StringVar(&baselinePath, "baseline", "", "Baseline file of approved technologies, so policies can assert on baseline status")
+	flags.IntVar(&timeout, "timeout", 15, "Query timeout in seconds")
+	flags.IntVar(&maxRecords, "max-records", 1000, "Maximum number of records to collect (prevents hangs)")
+	flags.BoolVar(&registrable, "apex", false, "Reduce each input to its registrable domain (e.g. www.example.co.uk -> example.co.uk)")
+	flags.BoolVar(&auditDelegation, "delegation", false, "Audit the delegation so policies can assert on it")
+	flags.BoolVar(&fetchMTASTS, "mta-sts-policy", false, "Fetch and validate the MTA-STS policy file over HTTPS")
+	flags.BoolVar(&jsonOutput, "json", false, "Print the report as JSON")
+	flags.BoolVar(&silentMode, "silent", false, "Silent mode - only the exit status reports the outcome")
+	flags.BoolVar(&verboseOutput, "verbose", false, "Also list the domains that pass")
+	flags.BoolVar(&debugMode, "debug", false, "Enable debug output")
+	flags.Parse(args)
+
+	if policyPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: Please provide a policy file with -policy")
+		flags.Usage()
+		return 2
+	}
+
+	var domains []string
+	switch {
+	case targetListFile != "":
+		list, err := readTargetList(targetListFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		domains = list
+	case domainName != "":
+		domains = []string{domainName}
+	default:
+		fmt.Fprintln(os.Stderr, "Error: Please provide a domain name with -domain flag or a target list with -l flag")
+		flags.Usage()
+		return 2
+	}
+
+	checks, err := policy.LoadFile(policyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+		return 2
+	}
+
+	// Policies may assert that there are no findings, so rules that can't be
+	// loaded must fail the check rather than pass it with no findings
+	var rules *analyzer.RuleSet
+	if rulesPath != "" {
+		rules, err = compileRules(rulesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	matcher, err := loadMatcher(signaturesPath, categoriesPath, includeCategories, excludeCategories, debugMode, silentMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	baseline, err := loadBaseline(baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	config := analyzer.Config{
+		Timeout:        time.Duration(timeout) * time.Second,
+		Debug:          debugMode && !silentMode,
+		MaxRecords:     maxRecords,
+		IncludeRecords: true, // policies may assert on allRecords
+		MTASTS: analyzer.MTASTSOptions{
+			FetchPolicy: fetchMTASTS,
+		},
+		AuditDelegation:   auditDelegation,
+		RegistrableDomain: registrable,
+		Rules:             rules,
+		Baseline:          baseline,
+	}
+
+	var report []checkedDomain
+	violations, failed := 0, 0
+	for _, domain := range domains {
+		config.Domain = domain
+		checked := checkedDomain{Domain: domain}
+
+		result, err := analyzer.AnalyzeDomain(config, matcher)
+		if err != nil {
+			failed++
+			checked.Error = err.Error()
+			if !silentMode && !jsonOutput {
+				fmt.Printf("ERROR %s: %v\n", domain, err)
+			}
+			report = append(report, checked)
+			continue
+		}
+
+		checked.Domain = result.Domain
+		checked.Violations = checks.Check(*result)
+		checked.Passed = len(checked.Violations) == 0
+		violations += len(checked.Violations)
+		report = append(report, checked)
+
+		if silentMode || jsonOutput {
+			continue
+		}
+		for _, violation := range checked.Violations {
+			fmt.Printf("FAIL %s [%s] %s\n", violation.Domain, violation.ID, violation.Message)
+		}
+		if checked.Passed && verboseOutput {
+			fmt.Printf("PASS %s\n", result.Domain)
+		}
+	}
+
+	if jsonOutput && !silentMode {
+		output, err := utils.FormatJSON(struct {
+			Violations int             `json:"violations"`
+			Failed     int             `json:"failed"`
+			Domains    []checkedDomain `json:"domains"`
+		}{violations, failed, report})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating JSON output: %v\n", err)
+			return 2
+		}
+		fmt.Println(output)
+	} else if !silentMode {
+		fmt.Fprintf(os.Stderr, "%d violations across %d domains checked against %d assertions", violations, len(domains), checks.Len())
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, ", %d domains could not be scanned", failed)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if violations > 0 || failed > 0 {
+		return 1
+	}
+	return 0
+}
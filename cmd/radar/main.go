@@ -4,12 +4,14 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/Elite-Security-Systems/radar/internal/analyzer"
+	"github.com/Elite-Security-Systems/radar/internal/dns"
 	"github.com/Elite-Security-Systems/radar/internal/models"
 	"github.com/Elite-Security-Systems/radar/internal/utils"
 	"github.com/Elite-Security-Systems/radar/pkg/signatures"
@@ -23,6 +25,18 @@ var (
 )
 
 func main() {
+	// Subcommands take their own flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "baseline":
+			os.Exit(runBaseline(os.Args[2:]))
+		case "redetect":
+			os.Exit(runRedetect(os.Args[2:]))
+		}
+	}
+
 	// Command line flags
 	var (
 		domainName        string
@@ -37,10 +51,28 @@ func main() {
 		silentMode        bool
 		outputPath        string
 		verboseOutput     bool
+		fetchMTASTS       bool
+		bimiSelectors     string
+		nsFingerprint     bool
+		nsExposure        bool
+		auditDelegation   bool
+		iterative         bool
+		rootHints         string
+		registrable       bool
+		minConfidence     int
+		categoriesPath    string
+		includeCategories string
+		excludeCategories string
+		rulesPath         string
+		baselinePath      string
+		zoneFiles         string
+		fromPath          string
 	)
 
 	flag.StringVar(&domainName, "domain", "", "Domain name to analyze")
 	flag.StringVar(&targetListFile, "l", "", "File containing list of domains to analyze (one per line)")
+	flag.StringVar(&zoneFiles, "zone", "", "Comma-separated RFC 1035 zone files to analyze offline instead of querying DNS")
+	flag.StringVar(&fromPath, "from", "", "Saved radar results with allRecords (file or directory) to analyze offline instead of querying DNS")
 	flag.StringVar(&signaturesPath, "signatures", "data/signatures.json", "Path to signatures file")
 	flag.BoolVar(&includeAllRecords, "all-records", false, "Include all records in JSON output")
 	flag.IntVar(&timeout, "timeout", 15, "Query timeout in seconds")
@@ -51,6 +83,20 @@ func main() {
 	flag.BoolVar(&silentMode, "silent", false, "Silent mode - suppress all non-error output")
 	flag.StringVar(&outputPath, "o", "", "Output file path or directory for results (if directory, creates JSON files named by domain)")
 	flag.BoolVar(&verboseOutput, "verbose", false, "Show progress information when processing multiple domains")
+	flag.BoolVar(&fetchMTASTS, "mta-sts-policy", false, "Fetch and validate the MTA-STS policy file over HTTPS")
+	flag.StringVar(&bimiSelectors, "bimi-selectors", "", "Comma-separated BIMI selectors to check in addition to 'default'")
+	flag.StringVar(&categoriesPath, "categories", "data/categories.json", "Path to the category taxonomy file")
+	flag.StringVar(&includeCategories, "include-category", "", "Comma-separated categories (IDs, names or aliases) to detect; subcategories are included")
+	flag.StringVar(&excludeCategories, "exclude-category", "", "Comma-separated categories (IDs, names or aliases) to leave out; subcategories are excluded too")
+	flag.StringVar(&rulesPath, "rules", "", "Misconfiguration rules file to check the records against (e.g. data/rules.json); off by default as the checks send extra queries")
+	flag.StringVar(&baselinePath, "baseline", "", "Baseline file of approved technologies; marks detections as expected or unexpected and lists missing ones")
+	flag.IntVar(&minConfidence, "min-confidence", 0, "Only report detections with at least this confidence (0-100)")
+	flag.BoolVar(&registrable, "apex", false, "Reduce each input to its registrable domain (e.g. www.example.co.uk -> example.co.uk)")
+	flag.BoolVar(&iterative, "iterative", false, "Resolve iteratively from the root servers instead of public resolvers, recording each referral")
+	flag.StringVar(&rootHints, "root-hints", "", "Comma-separated root server addresses (IP or IP:port) for -iterative")
+	flag.BoolVar(&auditDelegation, "delegation", false, "Audit the delegation: parent vs child NS, glue, lame servers and serial drift")
+	flag.BoolVar(&nsExposure, "ns-exposure", false, "Check each authoritative nameserver for open recursion and amplification")
+	flag.BoolVar(&nsFingerprint, "ns-fingerprint", false, "Fingerprint the software of each authoritative nameserver (CHAOS and NSID queries)")
 	flag.Parse()
 
 	// Show version information if requested
@@ -69,18 +115,27 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error updating signatures: %v\n", err)
 			os.Exit(1)
 		}
+		if err := signatures.DownloadSignatures(signatures.DefaultCategoriesURL, signatures.DefaultCategoriesCachePath); err != nil && !silentMode {
+			fmt.Fprintf(os.Stderr, "Warning: error updating categories: %v\n", err)
+		}
+		if err := signatures.DownloadSignatures(signatures.DefaultRulesURL, signatures.DefaultRulesCachePath); err != nil && !silentMode {
+			fmt.Fprintf(os.Stderr, "Warning: error updating rules: %v\n", err)
+		}
 		if verboseOutput && !silentMode {
 			fmt.Printf("Signatures updated successfully to %s\n", signatures.DefaultCachePath)
 		}
 		
 		// If no domain or target list provided, exit after update
-		if domainName == "" && targetListFile == "" {
+		if domainName == "" && targetListFile == "" && zoneFiles == "" && fromPath == "" {
 			os.Exit(0)
 		}
 	}
 
+	// Offline input is analyzed from the records it contains
+	offline := zoneFiles != "" || fromPath != ""
+
 	// Validate input: either domain or target list must be provided
-	if domainName == "" && targetListFile == "" {
+	if domainName == "" && targetListFile == "" && !offline {
 		fmt.Fprintln(os.Stderr, "Error: Please provide a domain name with -domain flag or a target list with -l flag")
 		flag.Usage()
 		os.Exit(1)
@@ -110,20 +165,76 @@ func main() {
 		}
 	}
 
-	// Load signatures
-	sigs, err := signatures.LoadFromFile(signaturesPath)
+	matcher, err := loadMatcher(signaturesPath, categoriesPath, includeCategories, excludeCategories, debugMode, silentMode)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading signatures: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	rules := loadRules(rulesPath, debugMode, silentMode)
+	baseline, err := loadBaseline(baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if debugMode && !silentMode {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Loaded %d signatures from %s\n", len(sigs.Signatures), signaturesPath)
+	// Analyzer settings shared by every target
+	baseConfig := analyzer.Config{
+		Timeout:        time.Duration(timeout) * time.Second,
+		Debug:          debugMode && !silentMode, // Disable debug output in silent mode
+		MaxRecords:     maxRecords,
+		IncludeRecords: includeAllRecords,
+		MTASTS: analyzer.MTASTSOptions{
+			FetchPolicy: fetchMTASTS,
+		},
+		BIMISelectors:          splitList(bimiSelectors),
+		FingerprintNameservers:  nsFingerprint,
+		CheckNameserverExposure: nsExposure,
+		AuditDelegation:         auditDelegation,
+		Iterative:               iterative,
+		RootHints:               splitList(rootHints),
+		RegistrableDomain:       registrable,
+		MinConfidence:           minConfidence,
+		Rules:                   rules,
+		Baseline:                baseline,
+	}
+
+	if minConfidence < 0 || minConfidence > 100 {
+		fmt.Fprintf(os.Stderr, "Error: -min-confidence must be between 0 and 100\n")
+		os.Exit(1)
+	}
+
+	for _, hint := range baseConfig.RootHints {
+		host := hint
+		if h, _, err := net.SplitHostPort(hint); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid root hint %q, expected an IP address\n", hint)
+			os.Exit(1)
+		}
+	}
+
+	// Analyze zone files and saved records without touching the network
+	if offline {
+		targets, err := loadOfflineTargets(splitList(zoneFiles), fromPath, domainName, silentMode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(targets) == 1 {
+			processSingleDomain(targets[0], outputPath, matcher, baseConfig, silentMode, verboseOutput)
+			os.Exit(0)
+		}
+		if err := processTargets(targets, outputPath, matcher, baseConfig, silentMode, verboseOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "Error processing offline input: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
 	// If target list is provided, process it
 	if targetListFile != "" {
-		err = processTargetList(targetListFile, outputPath, sigs, includeAllRecords, timeout, debugMode, maxRecords, silentMode, verboseOutput)
+		err := processTargetList(targetListFile, outputPath, matcher, baseConfig, silentMode, verboseOutput)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error processing target list: %v\n", err)
 			os.Exit(1)
@@ -132,21 +243,29 @@ func main() {
 	}
 
 	// Process single domain
-	processSingleDomain(domainName, outputPath, sigs, includeAllRecords, timeout, debugMode, maxRecords, silentMode, verboseOutput)
+	processSingleDomain(target{Domain: domainName}, outputPath, matcher, baseConfig, silentMode, verboseOutput)
 }
 
-// processSingleDomain analyzes a single domain and handles output
-func processSingleDomain(domain, outputPath string, sigs models.SignatureFile, includeAllRecords bool, timeout int, debugMode bool, maxRecords int, silentMode bool, verboseOutput bool) {
-	// Initialize analyzer with configuration
-	config := analyzer.Config{
-		Domain:         domain,
-		Timeout:        time.Duration(timeout) * time.Second,
-		Debug:          debugMode && !silentMode, // Disable debug output in silent mode
-		MaxRecords:     maxRecords,
-		IncludeRecords: includeAllRecords,
+// target is a domain to analyze, either live or from records read earlier
+type target struct {
+	Domain  string
+	Offline bool
+	Records []models.DNSResponse
+}
+
+// analyzeTarget scans a live target or analyzes an offline one's records
+func analyzeTarget(t target, config analyzer.Config, matcher *analyzer.Matcher) (*models.Result, error) {
+	config.Domain = t.Domain
+	if t.Offline {
+		return analyzer.AnalyzeRecords(config, matcher, t.Records)
 	}
-	
-	result, err := analyzer.AnalyzeDomain(config, sigs)
+	return analyzer.AnalyzeDomain(config, matcher)
+}
+
+// processSingleDomain analyzes a single domain and handles output
+func processSingleDomain(t target, outputPath string, matcher *analyzer.Matcher, config analyzer.Config, silentMode bool, verboseOutput bool) {
+	domain := t.Domain
+	result, err := analyzeTarget(t, config, matcher)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error analyzing domain %s: %v\n", domain, err)
 		return
@@ -192,63 +311,33 @@ func processSingleDomain(domain, outputPath string, sigs models.SignatureFile, i
 }
 
 // processTargetList reads domains from a file and processes each one
-func processTargetList(targetListFile, outputPath string, sigs models.SignatureFile, includeAllRecords bool, timeout int, debugMode bool, maxRecords int, silentMode bool, verboseOutput bool) error {
-	// Open the target list file
-	file, err := os.Open(targetListFile)
+func processTargetList(targetListFile, outputPath string, matcher *analyzer.Matcher, baseConfig analyzer.Config, silentMode bool, verboseOutput bool) error {
+	domains, err := readTargetList(targetListFile)
 	if err != nil {
-		return fmt.Errorf("error opening target list file: %v", err)
+		return err
 	}
-	defer file.Close()
 
+	targets := make([]target, 0, len(domains))
+	for _, domain := range domains {
+		targets = append(targets, target{Domain: domain})
+	}
+	return processTargets(targets, outputPath, matcher, baseConfig, silentMode, verboseOutput)
+}
+
+// processTargets analyzes every target and handles output
+func processTargets(targets []target, outputPath string, matcher *analyzer.Matcher, baseConfig analyzer.Config, silentMode bool, verboseOutput bool) error {
 	// If output path is a specific JSON file and we have multiple targets, we need to handle differently
 	isOutputFile := strings.HasSuffix(strings.ToLower(outputPath), ".json")
 	var combinedResults []models.Result
-	
-	// Count domains for progress indication
-	var totalDomains int
-	if verboseOutput {
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
-				totalDomains++
-			}
-		}
-		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("error reading target list file: %v", err)
-		}
 
-		// Reset file for processing
-		_, err = file.Seek(0, 0)
-		if err != nil {
-			return fmt.Errorf("error resetting file: %v", err)
-		}
-	}
-	
 	// Process each domain
-	scanner := bufio.NewScanner(file)
-	var processedDomains int
-	for scanner.Scan() {
-		// Get domain, skipping empty lines and comments
-		domain := strings.TrimSpace(scanner.Text())
-		if domain == "" || strings.HasPrefix(domain, "#") {
-			continue
-		}
-		
-		processedDomains++
+	for i, t := range targets {
+		domain := t.Domain
 		if verboseOutput && !silentMode {
-			fmt.Fprintf(os.Stderr, "Processing domain %d/%d: %s\n", processedDomains, totalDomains, domain)
+			fmt.Fprintf(os.Stderr, "Processing domain %d/%d: %s\n", i+1, len(targets), domain)
 		}
 
-		// Initialize analyzer with configuration
-		config := analyzer.Config{
-			Domain:         domain,
-			Timeout:        time.Duration(timeout) * time.Second,
-			Debug:          debugMode && !silentMode,
-			MaxRecords:     maxRecords,
-			IncludeRecords: includeAllRecords,
-		}
-		
-		result, err := analyzer.AnalyzeDomain(config, sigs)
+		result, err := analyzeTarget(t, baseConfig, matcher)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error analyzing domain %s: %v\n", domain, err)
 			continue
@@ -321,11 +410,165 @@ func processTargetList(targetListFile, outputPath string, sigs models.SignatureF
 		}
 	}
 
+	return nil
+}
+
+// loadMatcher loads the signatures, places them in the category taxonomy,
+// applies the category filters and compiles them once for every domain
+// analyzed. Missing signatures or a bad filter are returned as errors.
+func loadMatcher(signaturesPath, categoriesPath, includeCategories, excludeCategories string, debugMode, silentMode bool) (*analyzer.Matcher, error) {
+	sigs, err := signatures.LoadFromFile(signaturesPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading signatures: %v", err)
+	}
+
+	if debugMode && !silentMode {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Loaded %d signatures from %s\n", len(sigs.Signatures), signaturesPath)
+	}
+
+	// Without a taxonomy detection still works, just without category
+	// paths or filtering
+	taxonomy, err := signatures.LoadCategoriesFromFile(categoriesPath)
+	if err != nil {
+		if includeCategories != "" || excludeCategories != "" {
+			return nil, fmt.Errorf("error loading categories: %v", err)
+		}
+		if !silentMode {
+			fmt.Fprintf(os.Stderr, "Warning: error loading categories: %v\n", err)
+		}
+	} else {
+		sigs, err = analyzer.ApplyTaxonomy(sigs, taxonomy)
+		if err != nil && !silentMode {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		filter, err := analyzer.NewCategoryFilter(taxonomy, splitList(includeCategories), splitList(excludeCategories))
+		if err != nil {
+			return nil, err
+		}
+		sigs = analyzer.FilterSignatures(sigs, filter)
+		if debugMode && !silentMode && !filter.Empty() {
+			fmt.Fprintf(os.Stderr, "[DEBUG] %d signatures left after category filtering\n", len(sigs.Signatures))
+		}
+	}
+
+	matcher, err := analyzer.NewMatcher(sigs)
+	if err != nil && !silentMode {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return matcher, nil
+}
+
+// loadRules compiles the misconfiguration rules. Without them the scan still
+// runs, it just reports no findings, so problems are only warnings.
+func loadRules(rulesPath string, debugMode, silentMode bool) *analyzer.RuleSet {
+	if rulesPath == "" {
+		return nil
+	}
+
+	rules, err := compileRules(rulesPath)
+	if err != nil && !silentMode {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if rules != nil && debugMode && !silentMode {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Loaded %d rules from %s\n", rules.Len(), rulesPath)
+	}
+	return rules
+}
+
+// compileRules loads and compiles the rules file. When some rules are
+// invalid, the set of valid ones is returned along with the error.
+func compileRules(rulesPath string) (*analyzer.RuleSet, error) {
+	ruleFile, err := signatures.LoadRulesFromFile(rulesPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading rules: %v", err)
+	}
+	return analyzer.NewRuleSet(ruleFile)
+}
+
+// loadBaseline reads the baseline file, if one is given. A baseline that
+// can't be read is an error rather than a reason to flag every detection.
+func loadBaseline(baselinePath string) (*models.Baseline, error) {
+	if baselinePath == "" {
+		return nil, nil
+	}
+	baseline, err := utils.LoadBaseline(baselinePath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading baseline: %v", err)
+	}
+	return baseline, nil
+}
+
+// loadOfflineTargets reads zone files and saved results. A domain given with
+// -domain is the origin for relative names in a single zone file. Saved
+// results without allRecords can't be analyzed again and are skipped.
+func loadOfflineTargets(zoneFiles []string, fromPath, origin string, silentMode bool) ([]target, error) {
+	if len(zoneFiles) > 1 {
+		origin = ""
+	}
+
+	var targets []target
+	for _, path := range zoneFiles {
+		zone, records, err := dns.ParseZoneFile(path, origin)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		targets = append(targets, target{Domain: strings.TrimSuffix(zone, "."), Offline: true, Records: records})
+	}
+
+	if fromPath != "" {
+		results, err := utils.LoadResults(fromPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading results: %v", err)
+		}
+		for _, result := range results {
+			if len(result.AllRecords) == 0 {
+				if !silentMode {
+					fmt.Fprintf(os.Stderr, "Warning: skipping %s, saved without allRecords\n", result.Domain)
+				}
+				continue
+			}
+			targets = append(targets, target{Domain: result.Domain, Offline: true, Records: result.AllRecords})
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no records to analyze")
+	}
+	return targets, nil
+}
+
+// readTargetList returns the domains in a target list file, skipping empty
+// lines and comments
+func readTargetList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening target list file: %v", err)
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, line)
+		}
+	}
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading target list file: %v", err)
+		return nil, fmt.Errorf("error reading target list file: %v", err)
 	}
+	return domains, nil
+}
 
-	return nil
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // printVersion prints version information
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/analyzer"
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/Elite-Security-Systems/radar/internal/utils"
+)
+
+// runRedetect runs the current signatures over the records in saved results
+// and reports how the detections changed, optionally writing updated copies
+// of the results
+func runRedetect(args []string) int {
+	var (
+		fromPath          string
+		outputDir         string
+		signaturesPath    string
+		categoriesPath    string
+		includeCategories string
+		excludeCategories string
+		baselinePath      string
+		minConfidence     int
+		jsonOutput        bool
+		silentMode        bool
+		verboseOutput     bool
+		debugMode         bool
+	)
+
+	flags := flag.NewFlagSet("redetect", flag.ExitOnError)
+	flags.StringVar(&fromPath, "from", "", "Saved radar results (file or directory) with allRecords to detect again")
+	flags.StringVar(&outputDir, "o", "", "Directory to write the updated results to, keeping their file names and layout")
+	flags.StringVar(&signaturesPath, "signatures", "data/signatures.json", "Path to signatures file")
+	flags.StringVar(&categoriesPath, "categories", "data/categories.json", "Path to the category taxonomy file")
+	flags.StringVar(&includeCategories, "include-category", "", "Comma-separated categories (IDs, names or aliases) to detect; subcategories are included")
+	flags.StringVar(&excludeCategories, "exclude-category", "", "Comma-separated categories (IDs, names or aliases) to leave out; subcategories are excluded too")
+	flags.StringVar(&baselinePath, "baseline", "", "Baseline file to compare the new detections with")
+	flags.IntVar(&minConfidence, "min-confidence", 0, "Only keep detections with at least this confidence (0-100)")
+	flags.BoolVar(&jsonOutput, "json", false, "Print the changes as JSON")
+	flags.BoolVar(&silentMode, "silent", false, "Silent mode - suppress all non-error output")
+	flags.BoolVar(&verboseOutput, "verbose", false, "Also list results whose detections didn't change")
+	flags.BoolVar(&debugMode, "debug", false, "Enable debug output")
+	flags.Parse(args)
+
+	if fromPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: Please provide saved results with -from")
+		flags.Usage()
+		return 1
+	}
+
+	files, err := utils.LoadResultFiles(fromPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading results: %v\n", err)
+		return 1
+	}
+	matcher, err := loadMatcher(signaturesPath, categoriesPath, includeCategories, excludeCategories, debugMode, silentMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	baseline, err := loadBaseline(baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	diffs := []models.DetectionDiff{}
+	total, changed, skipped, added, removed := 0, 0, 0, 0, 0
+	for _, file := range files {
+		for i := range file.Results {
+			result := &file.Results[i]
+			total++
+
+			diff, ok := analyzer.Redetect(result, matcher, minConfidence)
+			if !ok {
+				skipped++
+				if debugMode && !silentMode {
+					fmt.Fprintf(os.Stderr, "Skipping %s in %s: saved without allRecords\n", result.Domain, file.Path)
+				}
+				continue
+			}
+			diff.Source = file.Path
+
+			// The old baseline comparison described the old detections
+			if baseline != nil {
+				analyzer.ApplyBaseline(result, baseline, matcher, matcher.Detect(result.AllRecords))
+			} else if !diff.Empty() {
+				result.Baseline = nil
+			}
+
+			if !diff.Empty() {
+				changed++
+				added += len(diff.Added)
+				removed += len(diff.Removed)
+			}
+			if !diff.Empty() || verboseOutput {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	if !silentMode {
+		if jsonOutput {
+			output, err := utils.FormatJSON(diffs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating JSON output: %v\n", err)
+				return 1
+			}
+			fmt.Println(output)
+		} else {
+			for _, diff := range diffs {
+				printDetectionDiff(diff)
+			}
+		}
+	}
+
+	if outputDir != "" {
+		for _, file := range files {
+			path := filepath.Join(outputDir, redetectOutputName(fromPath, file.Path))
+			if err := utils.SaveResultFile(file, path); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving results: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	if !silentMode {
+		fmt.Fprintf(os.Stderr, "%d of %d results changed: %d detections added, %d removed", changed, total, added, removed)
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, ", %d skipped without allRecords", skipped)
+		}
+		fmt.Fprintln(os.Stderr)
+		if outputDir != "" {
+			fmt.Fprintf(os.Stderr, "Updated results saved to: %s\n", outputDir)
+		}
+	}
+	return 0
+}
+
+// printDetectionDiff prints the changes for one result, one line per
+// technology
+func printDetectionDiff(diff models.DetectionDiff) {
+	fmt.Printf("%s (%s)\n", diff.Domain, diff.Source)
+	if diff.Empty() {
+		fmt.Println("  unchanged")
+	}
+	for _, name := range diff.Added {
+		fmt.Printf("  + %s\n", name)
+	}
+	for _, name := range diff.Removed {
+		fmt.Printf("  - %s\n", name)
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf("  ~ %s: confidence %d -> %d\n", change.Name, change.Before, change.After)
+	}
+}
+
+// redetectOutputName is where an updated results file goes within the output
+// directory: its path below the input directory, or its name when a single
+// file was given
+func redetectOutputName(fromPath, path string) string {
+	if rel, err := filepath.Rel(fromPath, path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return filepath.Base(path)
+}
@@ -2,11 +2,15 @@ module github.com/Elite-Security-Systems/radar
 
 go 1.22.4
 
-require github.com/miekg/dns v1.1.55
+require (
+	github.com/miekg/dns v1.1.55
+	golang.org/x/net v0.2.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	golang.org/x/mod v0.7.0 // indirect
-	golang.org/x/net v0.2.0 // indirect
 	golang.org/x/sys v0.2.0 // indirect
+	golang.org/x/text v0.4.0 // indirect
 	golang.org/x/tools v0.3.0 // indirect
 )
@@ -4,30 +4,66 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/Elite-Security-Systems/radar/internal/dns"
 	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/Elite-Security-Systems/radar/internal/utils"
 )
 
 // Config contains the configuration for the analyzer
 type Config struct {
+	// Domain is the name to analyze as the user gave it: a domain, URL or
+	// email address. It is normalized before any query is sent.
 	Domain         string
 	Timeout        time.Duration
 	Debug          bool
 	MaxRecords     int
 	IncludeRecords bool
+	MTASTS         MTASTSOptions
+	BIMISelectors  []string
+
+	// FingerprintNameservers sends identification queries to every
+	// authoritative nameserver of the domain
+	FingerprintNameservers bool
+	// CheckNameserverExposure probes every authoritative nameserver for
+	// open recursion and reflection amplification
+	CheckNameserverExposure bool
+	// AuditDelegation compares the delegation at the parent zone with the
+	// zone's NS records and each nameserver's answers
+	AuditDelegation bool
+	// Iterative resolves every query from the root servers instead of using
+	// public recursive resolvers, and records the referral steps
+	Iterative bool
+	// RootHints overrides the root server addresses used by Iterative
+	RootHints []string
+	// RegistrableDomain reduces the input to its registrable domain (eTLD+1)
+	RegistrableDomain bool
+	// MinConfidence drops detections scoring below it (0-100)
+	MinConfidence int
+	// Rules reports misconfigurations found in the records. It is compiled
+	// once and shared by every domain; nil skips the checks.
+	Rules *RuleSet
+	// Baseline marks detections as expected or unexpected and reports
+	// approved technologies that are missing; nil skips the comparison
+	Baseline *models.Baseline
+}
+
+// Resolver answers the targeted single-name lookups made by the record
+// analyzers (SPF and friends). *dns.Client satisfies it; tests use fakes.
+type Resolver interface {
+	Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error)
 }
 
 // AnalyzeDomain performs a complete analysis of a domain
-func AnalyzeDomain(config Config, signatures models.SignatureFile) (*models.Result, error) {
-	// Normalize domain
-	domain := config.Domain
-	if !strings.HasSuffix(domain, ".") {
-		domain = domain + "."
+func AnalyzeDomain(config Config, matcher *Matcher) (*models.Result, error) {
+	// Normalize the input and reject invalid names before sending any query
+	name, err := utils.NormalizeDomain(config.Domain, config.RegistrableDomain)
+	if err != nil {
+		return nil, err
 	}
+	domain := name + "."
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
@@ -35,8 +71,11 @@ func AnalyzeDomain(config Config, signatures models.SignatureFile) (*models.Resu
 
 	// Query all DNS records
 	dnsClient := dns.NewClient(config.Debug)
+	if config.Iterative {
+		dnsClient = dns.NewIterativeClient(config.Debug, config.RootHints)
+	}
 	allRecords, err := dnsClient.QueryAllRecords(ctx, domain, config.Timeout/2, config.MaxRecords)
-	
+
 	// Continue with partial results even if we hit timeout
 	if err != nil && err != context.DeadlineExceeded {
 		return nil, fmt.Errorf("error querying DNS records: %w", err)
@@ -46,13 +85,95 @@ func AnalyzeDomain(config Config, signatures models.SignatureFile) (*models.Resu
 		fmt.Fprintf(os.Stderr, "[DEBUG] Query timeout reached, proceeding with collected records\n")
 	}
 
+	// Probe the authoritative nameservers directly
+	var nameservers []models.Nameserver
+	if config.FingerprintNameservers || config.CheckNameserverExposure {
+		nameservers = AnalyzeNameservers(ctx, dnsClient, dnsClient, domain, allRecords, NameserverOptions{
+			Fingerprint: config.FingerprintNameservers,
+			Exposure:    config.CheckNameserverExposure,
+		})
+		allRecords = mergeRecords(allRecords, nameserverRecords(nameservers))
+	}
+
+	var delegation *models.DelegationResult
+	if config.AuditDelegation {
+		delegation = AnalyzeDelegation(ctx, dnsClient, dnsClient, domain, allRecords)
+	}
+
+	result := analyzeRecords(ctx, config, matcher, name, dnsClient, allRecords)
+	result.Nameservers = nameservers
+	result.Delegation = delegation
+	result.Findings = append(result.Findings, probeFindings(nameservers, delegation)...)
+	result.Trace = dnsClient.Traces()
+	return result, nil
+}
+
+// AnalyzeRecords analyzes records collected earlier, such as a zone file or
+// saved results, without any network access. Lookups made by the record
+// analyzers are answered from the records; names outside the domain that the
+// records don't cover fail as lookup errors. Probes that need live servers (nameservers, delegation and the
+// MTA-STS policy file) are skipped.
+func AnalyzeRecords(config Config, matcher *Matcher, records []models.DNSResponse) (*models.Result, error) {
+	name, err := utils.NormalizeDomain(config.Domain, config.RegistrableDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	config.MTASTS.FetchPolicy = false
+	return analyzeRecords(context.Background(), config, matcher, name, newRecordResolver(name, records), records), nil
+}
+
+// analyzeRecords runs the record analyzers and technology detection over a
+// domain's records. Lookups the analyzers make go through resolver.
+func analyzeRecords(ctx context.Context, config Config, matcher *Matcher, name string, resolver Resolver, allRecords []models.DNSResponse) *models.Result {
+	domain := name + "."
+
+	// Evaluate the mail authentication policies published by the domain. Records
+	// found at the policy names feed technology detection alongside the rest.
+	recorder := &recordingResolver{Resolver: resolver}
+	spfResult := AnalyzeSPF(ctx, resolver, domain)
+	dmarcResult := AnalyzeDMARC(ctx, recorder, domain)
+	mtaSTSResult := AnalyzeMTASTS(ctx, recorder, domain, mxHostsFromRecords(allRecords), config.MTASTS)
+	tlsRPTResult := AnalyzeTLSRPT(ctx, recorder, domain)
+	bimiResult := AnalyzeBIMI(ctx, recorder, domain, config.BIMISelectors, dmarcResult)
+	allRecords = mergeRecords(allRecords, recorder.Records())
+
+	// Work out which certificate authorities may issue for the domain
+	caaResult := AnalyzeCAA(ctx, resolver, domain)
+
 	// Detect technologies from the records
-	detectedTechnologies := DetectTechnologies(allRecords, signatures)
+	detections := matcher.Detect(allRecords)
+	detectedTechnologies := FilterByConfidence(detections, config.MinConfidence)
 
 	// Prepare result
 	result := &models.Result{
-		Domain:               strings.TrimSuffix(domain, "."),
+		Domain:               name,
 		DetectedTechnologies: detectedTechnologies,
+		SPF:                  spfResult,
+		DMARC:                dmarcResult,
+		MTASTS:               mtaSTSResult,
+		TLSRPT:               tlsRPTResult,
+		BIMI:                 bimiResult,
+		ServiceBindings:      AnalyzeServiceBindings(allRecords, config.Debug),
+		CAA:                  caaResult,
+	}
+	if config.Rules != nil {
+		// Wildcard records only show up at names that don't exist, so the
+		// rules also see the answers for a random name. They stay out of
+		// detection and allRecords. CAA and DNSSEC depend on records above
+		// the name, so they are checked against the analysis and targeted
+		// lookups instead.
+		result.Findings = config.Rules.Evaluate(mergeRecords(allRecords, wildcardRecords(ctx, resolver, domain)))
+		result.Findings = append(result.Findings, caaFindings(caaResult)...)
+		result.Findings = append(result.Findings, dnssecFindings(ctx, resolver, name)...)
+	}
+	if config.Baseline != nil {
+		ApplyBaseline(result, config.Baseline, matcher, detections)
+	}
+
+	// Keep the original input when normalization changed it
+	if config.Domain != name {
+		result.Input = config.Domain
 	}
 
 	// Include all records if requested
@@ -60,23 +181,38 @@ func AnalyzeDomain(config Config, signatures models.SignatureFile) (*models.Resu
 		result.AllRecords = allRecords
 	}
 
-	return result, nil
+	return result
+}
+
+// probeFindings collects the findings of the nameserver and delegation
+// probes, so every finding also shows up in the result's findings
+func probeFindings(nameservers []models.Nameserver, delegation *models.DelegationResult) []models.Finding {
+	var findings []models.Finding
+	for _, nameserver := range nameservers {
+		if nameserver.Exposure != nil {
+			findings = append(findings, nameserver.Exposure.Findings...)
+		}
+	}
+	if delegation != nil {
+		findings = append(findings, delegation.Findings...)
+	}
+	return findings
 }
 
 // aggregateResults collects and deduplicates DNS records from all resolvers
 func aggregateResults(responsesMap map[string]models.DNSResponse) []models.DNSResponse {
 	var responses []models.DNSResponse
-	
+
 	// Mutex to protect concurrent access to the responses slice
 	var mutex sync.Mutex
-	
+
 	// Create a map to track seen records and avoid duplicates
 	seen := make(map[string]bool)
-	
+
 	for _, response := range responsesMap {
 		// Create a unique key based on the record type and value
 		key := fmt.Sprintf("%s-%s-%s", response.Domain, response.RecordType, response.Value)
-		
+
 		mutex.Lock()
 		if !seen[key] {
 			seen[key] = true
@@ -84,6 +220,46 @@ func aggregateResults(responsesMap map[string]models.DNSResponse) []models.DNSRe
 		}
 		mutex.Unlock()
 	}
-	
+
 	return responses
 }
+
+// recordingResolver remembers every record returned by the wrapped resolver
+type recordingResolver struct {
+	Resolver
+	mutex   sync.Mutex
+	records []models.DNSResponse
+}
+
+// Lookup forwards the query and keeps a copy of the answer
+func (r *recordingResolver) Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error) {
+	responses, err := r.Resolver.Lookup(ctx, name, recordType)
+	r.mutex.Lock()
+	r.records = append(r.records, responses...)
+	r.mutex.Unlock()
+	return responses, err
+}
+
+// Records returns everything recorded so far
+func (r *recordingResolver) Records() []models.DNSResponse {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]models.DNSResponse(nil), r.records...)
+}
+
+// mergeRecords appends extra records to a record set, skipping duplicates
+func mergeRecords(records []models.DNSResponse, extra []models.DNSResponse) []models.DNSResponse {
+	seen := make(map[string]bool)
+	for _, record := range records {
+		seen[fmt.Sprintf("%s-%s-%s", record.Domain, record.RecordType, record.Value)] = true
+	}
+
+	for _, record := range extra {
+		key := fmt.Sprintf("%s-%s-%s", record.Domain, record.RecordType, record.Value)
+		if !seen[key] {
+			seen[key] = true
+			records = append(records, record)
+		}
+	}
+	return records
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// ApplyBaseline marks each detection in the result as expected or
+// unexpected and lists the baseline technologies that are no longer
+// detected. A detection is expected when the baseline names it or one of the
+// categories on its path.
+//
+// unfiltered holds the matcher's detections before -min-confidence dropped
+// any. Technologies found there, or without a signature in the matcher
+// because a category filter removed it, are not reported as missing. A nil
+// matcher only compares against the result's detections.
+func ApplyBaseline(result *models.Result, baseline *models.Baseline, matcher *Matcher, unfiltered []models.DetectedTechnology) {
+	entry := baseline.Entry(result.Domain)
+	comparison := &models.BaselineResult{}
+
+	detected := make(map[string]bool)
+	for i := range result.DetectedTechnologies {
+		tech := &result.DetectedTechnologies[i]
+		detected[strings.ToLower(tech.Name)] = true
+
+		if containsFold(entry.Technologies, tech.Name) || approvedCategory(entry.Categories, *tech) {
+			tech.Baseline = models.BaselineExpected
+			comparison.Expected = append(comparison.Expected, tech.Name)
+		} else {
+			tech.Baseline = models.BaselineUnexpected
+			comparison.Unexpected = append(comparison.Unexpected, tech.Name)
+		}
+	}
+
+	for _, tech := range unfiltered {
+		detected[strings.ToLower(tech.Name)] = true
+	}
+
+	for _, name := range entry.Technologies {
+		if matcher != nil && !matcher.Has(name) {
+			continue
+		}
+		if !detected[strings.ToLower(name)] && !containsFold(comparison.Missing, name) {
+			comparison.Missing = append(comparison.Missing, name)
+		}
+	}
+
+	result.Baseline = comparison
+}
+
+// approvedCategory reports whether a detection's category, by name or by
+// any ID on its path, is among the approved categories
+func approvedCategory(categories []string, tech models.DetectedTechnology) bool {
+	if containsFold(categories, tech.Category) {
+		return true
+	}
+	for _, id := range tech.CategoryPath {
+		if containsFold(categories, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateBaseline approves every technology detected in known-good results.
+// A domain found in several results gets the technologies of all of them.
+func GenerateBaseline(results []models.Result) models.Baseline {
+	baseline := models.Baseline{
+		Generated: time.Now().Format(time.RFC3339),
+		Domains:   make(map[string]models.BaselineEntry),
+	}
+
+	for _, result := range results {
+		key := models.BaselineKey(result.Domain)
+		entry := baseline.Domains[key]
+		for _, tech := range result.DetectedTechnologies {
+			if !containsFold(entry.Technologies, tech.Name) {
+				entry.Technologies = append(entry.Technologies, tech.Name)
+			}
+		}
+		sort.Strings(entry.Technologies)
+		baseline.Domains[key] = entry
+	}
+	return baseline
+}
+
+// containsFold reports whether list holds value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+func TestApplyBaseline(t *testing.T) {
+	baseline := &models.Baseline{Domains: map[string]models.BaselineEntry{
+		"example.com": {Technologies: []string{"Google Workspace", "Cloudflare DNS"}},
+		"*":           {Categories: []string{"email-security"}},
+	}}
+
+	result := &models.Result{
+		Domain: "example.com",
+		DetectedTechnologies: []models.DetectedTechnology{
+			{Name: "google workspace", CategoryPath: []string{"email", "email-hosting"}},
+			{Name: "Mimecast", Category: "Email Security", CategoryPath: []string{"email", "email-security"}},
+			{Name: "HubSpot", CategoryPath: []string{"marketing"}},
+		},
+	}
+	ApplyBaseline(result, baseline, nil, nil)
+
+	var states []string
+	for _, tech := range result.DetectedTechnologies {
+		states = append(states, tech.Baseline)
+	}
+	if expected := []string{"expected", "expected", "unexpected"}; !reflect.DeepEqual(states, expected) {
+		t.Errorf("Expected states %v, got %v", expected, states)
+	}
+
+	expected := &models.BaselineResult{
+		Expected:   []string{"google workspace", "Mimecast"},
+		Unexpected: []string{"HubSpot"},
+		Missing:    []string{"Cloudflare DNS"},
+	}
+	if !reflect.DeepEqual(result.Baseline, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, result.Baseline)
+	}
+
+	// Domains missing from the baseline only get the entry for every domain
+	other := &models.Result{Domain: "other.example", DetectedTechnologies: []models.DetectedTechnology{{Name: "HubSpot"}}}
+	ApplyBaseline(other, baseline, nil, nil)
+	if other.DetectedTechnologies[0].Baseline != models.BaselineUnexpected || len(other.Baseline.Missing) != 0 {
+		t.Errorf("Unexpected comparison %+v", other.Baseline)
+	}
+}
+
+func TestBaselineWithFilteredDetections(t *testing.T) {
+	// "Retired Service" was left out of the signatures, as a category filter
+	// would, so the scan can't say whether it is still in use
+	matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Google Workspace", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "google.com"}}},
+		{Name: "Verification Service", RecordTypes: []string{"TXT"}, Patterns: []models.Pattern{{Prefix: "service-verification="}}},
+		{Name: "Mail Relay", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "relay.example"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	baseline := &models.Baseline{Domains: map[string]models.BaselineEntry{
+		"example.com": {Technologies: []string{"Google Workspace", "Verification Service", "Mail Relay", "Retired Service"}},
+	}}
+	records := []models.DNSResponse{
+		{Domain: "example.com.", RecordType: "MX", Value: "1 aspmx.l.google.com."},
+		{Domain: "example.com.", RecordType: "TXT", Value: "service-verification=abc123"},
+	}
+
+	// The TXT detection falls below the minimum confidence, but its record
+	// is still there
+	result, err := AnalyzeRecords(Config{Domain: "example.com", MinConfidence: 70, Baseline: baseline}, matcher, records)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.DetectedTechnologies) != 1 || result.DetectedTechnologies[0].Name != "Google Workspace" {
+		t.Errorf("Expected only Google Workspace, got %v", result.DetectedTechnologies)
+	}
+	if expected := []string{"Mail Relay"}; !reflect.DeepEqual(result.Baseline.Missing, expected) {
+		t.Errorf("Expected missing %v, got %v", expected, result.Baseline.Missing)
+	}
+}
+
+func TestGenerateBaseline(t *testing.T) {
+	baseline := GenerateBaseline([]models.Result{
+		{Domain: "example.com", DetectedTechnologies: []models.DetectedTechnology{{Name: "Mimecast"}, {Name: "Cloudflare DNS"}}},
+		{Domain: "Example.com.", DetectedTechnologies: []models.DetectedTechnology{{Name: "Google Workspace"}, {Name: "Mimecast"}}},
+		{Domain: "quiet.example"},
+	})
+
+	expected := map[string]models.BaselineEntry{
+		"example.com":   {Technologies: []string{"Cloudflare DNS", "Google Workspace", "Mimecast"}},
+		"quiet.example": {},
+	}
+	if !reflect.DeepEqual(baseline.Domains, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, baseline.Domains)
+	}
+}
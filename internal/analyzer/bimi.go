@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// BIMI evaluation statuses
+const (
+	BIMIStatusNone      = "none"
+	BIMIStatusValid     = "valid"
+	BIMIStatusInvalid   = "invalid"
+	BIMIStatusDeclined  = "declined"
+	BIMIStatusTempError = "temperror"
+)
+
+// DefaultBIMISelector is the selector mailbox providers look up unless the
+// message names another one in its BIMI-Selector header
+const DefaultBIMISelector = "default"
+
+// AnalyzeBIMI looks up the BIMI records for the default selector and any extra
+// selectors, and checks them against the DMARC enforcement prerequisite
+func AnalyzeBIMI(ctx context.Context, resolver Resolver, domain string, selectors []string, dmarc *models.DMARCResult) *models.BIMIResult {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	result := &models.BIMIResult{}
+
+	// BIMI requires an enforced DMARC policy that also covers subdomains
+	result.DMARCEnforced = dmarc != nil && dmarc.Enforced && dmarc.SubdomainPolicy != "none"
+	switch {
+	case dmarc == nil || dmarc.Status == DMARCStatusNone:
+		result.Issues = append(result.Issues, "domain has no DMARC record, BIMI requires p=quarantine or p=reject")
+	case dmarc.Status != DMARCStatusValid:
+		result.Issues = append(result.Issues, "domain's DMARC record is not valid")
+	case !dmarc.Enforced:
+		result.Issues = append(result.Issues, fmt.Sprintf("DMARC policy p=%s pct=%d is not at enforcement, BIMI requires p=quarantine or p=reject at pct=100", dmarc.Policy, dmarc.Percentage))
+	case dmarc.SubdomainPolicy == "none":
+		result.Issues = append(result.Issues, "DMARC subdomain policy sp=none disqualifies the domain from BIMI")
+	}
+
+	seen := make(map[string]bool)
+	for _, selector := range append([]string{DefaultBIMISelector}, selectors...) {
+		selector = strings.ToLower(strings.TrimSpace(selector))
+		if selector == "" || seen[selector] {
+			continue
+		}
+		seen[selector] = true
+
+		entry := lookupBIMISelector(ctx, resolver, domain, selector)
+		result.Selectors = append(result.Selectors, entry)
+
+		if entry.Status == BIMIStatusValid && result.DMARCEnforced {
+			result.Eligible = true
+		}
+	}
+
+	hasRecord := false
+	hasAuthority := false
+	for _, entry := range result.Selectors {
+		if entry.Status == BIMIStatusValid {
+			hasRecord = true
+			if entry.AuthorityURL != "" {
+				hasAuthority = true
+			}
+		}
+	}
+	if !hasRecord {
+		result.Issues = append(result.Issues, "no valid BIMI record published")
+	} else if !hasAuthority {
+		result.Issues = append(result.Issues, "no mark certificate (a= tag), Gmail and Apple Mail only display logos backed by a VMC or CMC")
+	}
+
+	return result
+}
+
+// lookupBIMISelector fetches and parses the record at <selector>._bimi.<domain>
+func lookupBIMISelector(ctx context.Context, resolver Resolver, domain, selector string) models.BIMISelector {
+	entry := models.BIMISelector{Selector: selector, Status: BIMIStatusNone}
+
+	records, err := resolver.Lookup(ctx, selector+"._bimi."+domain, "TXT")
+	if err != nil {
+		entry.Status = BIMIStatusTempError
+		entry.Errors = append(entry.Errors, fmt.Sprintf("error looking up BIMI record for selector %s: %v", selector, err))
+		return entry
+	}
+
+	var bimiRecords []string
+	for _, record := range records {
+		if record.RecordType == "TXT" && hasVersionTag(record.Value, "BIMI1") {
+			bimiRecords = append(bimiRecords, record.Value)
+		}
+	}
+	if len(bimiRecords) == 0 {
+		return entry
+	}
+
+	entry.Record = bimiRecords[0]
+	entry.Status = BIMIStatusValid
+	if len(bimiRecords) > 1 {
+		invalidBIMI(&entry, "multiple BIMI records published for the selector")
+		return entry
+	}
+
+	hasLogoTag := false
+	for _, part := range strings.Split(entry.Record, ";") {
+		tag, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		value = strings.TrimSpace(value)
+		if !found {
+			continue
+		}
+
+		switch tag {
+		case "l":
+			hasLogoTag = true
+			entry.LogoURL = value
+		case "a":
+			entry.AuthorityURL = value
+		}
+	}
+
+	// An empty l= (and a=) is a declination to publish BIMI
+	if hasLogoTag && entry.LogoURL == "" && entry.AuthorityURL == "" {
+		entry.Status = BIMIStatusDeclined
+		return entry
+	}
+
+	if entry.LogoURL == "" {
+		invalidBIMI(&entry, "BIMI record has no logo location (l= tag)")
+	} else {
+		checkBIMIURL(&entry, "logo", entry.LogoURL, ".svg")
+	}
+	if entry.AuthorityURL != "" {
+		checkBIMIURL(&entry, "mark certificate", entry.AuthorityURL, ".pem")
+	}
+
+	return entry
+}
+
+// checkBIMIURL validates that a BIMI location is an HTTPS URL and warns if
+// it doesn't carry the expected file extension
+func checkBIMIURL(entry *models.BIMISelector, kind, location, extension string) {
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Host == "" {
+		invalidBIMI(entry, fmt.Sprintf("%s location %q is not a valid URL", kind, location))
+		return
+	}
+	if !strings.EqualFold(parsed.Scheme, "https") {
+		invalidBIMI(entry, fmt.Sprintf("%s location %q must use HTTPS", kind, location))
+		return
+	}
+	if !strings.HasSuffix(strings.ToLower(parsed.Path), extension) {
+		entry.Warnings = append(entry.Warnings, fmt.Sprintf("%s location %q does not end in %s", kind, location, extension))
+	}
+}
+
+// invalidBIMI marks a selector's record invalid and records why
+func invalidBIMI(entry *models.BIMISelector, message string) {
+	entry.Status = BIMIStatusInvalid
+	entry.Errors = appendUnique(entry.Errors, message)
+}
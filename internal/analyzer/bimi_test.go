@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+func TestAnalyzeBIMI(t *testing.T) {
+	enforced := &models.DMARCResult{Status: DMARCStatusValid, Policy: "reject", SubdomainPolicy: "reject", Percentage: 100, Enforced: true}
+	monitoring := &models.DMARCResult{Status: DMARCStatusValid, Policy: "none", SubdomainPolicy: "none", Percentage: 100}
+
+	testCases := []struct {
+		name      string
+		records   fakeResolver
+		selectors []string
+		dmarc     *models.DMARCResult
+		statuses  []string
+		eligible  bool
+		issues    int
+	}{
+		{
+			name:     "No BIMI record",
+			records:  fakeResolver{},
+			dmarc:    enforced,
+			statuses: []string{BIMIStatusNone},
+			issues:   1,
+		},
+		{
+			name: "Valid record with mark certificate",
+			records: fakeResolver{
+				"default._bimi.example.com TXT": {"v=BIMI1; l=https://example.com/logo.svg; a=https://example.com/vmc.pem"},
+			},
+			dmarc:    enforced,
+			statuses: []string{BIMIStatusValid},
+			eligible: true,
+		},
+		{
+			name: "Valid record without DMARC enforcement",
+			records: fakeResolver{
+				"default._bimi.example.com TXT": {"v=BIMI1; l=https://example.com/logo.svg; a=https://example.com/vmc.pem"},
+			},
+			dmarc:    monitoring,
+			statuses: []string{BIMIStatusValid},
+			issues:   1,
+		},
+		{
+			name: "Extra selector and insecure logo",
+			records: fakeResolver{
+				"default._bimi.example.com TXT":   {"v=BIMI1; l=; a=;"},
+				"marketing._bimi.example.com TXT": {"v=BIMI1; l=http://example.com/logo.svg"},
+			},
+			selectors: []string{"marketing", "default"},
+			dmarc:     enforced,
+			statuses:  []string{BIMIStatusDeclined, BIMIStatusInvalid},
+			issues:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := AnalyzeBIMI(context.Background(), tc.records, "example.com.", tc.selectors, tc.dmarc)
+
+			if len(result.Selectors) != len(tc.statuses) {
+				t.Fatalf("Expected %d selectors, got %+v", len(tc.statuses), result.Selectors)
+			}
+			for i, status := range tc.statuses {
+				if result.Selectors[i].Status != status {
+					t.Errorf("Expected selector %s status %s, got %s (errors: %v)",
+						result.Selectors[i].Selector, status, result.Selectors[i].Status, result.Selectors[i].Errors)
+				}
+			}
+			if result.Eligible != tc.eligible {
+				t.Errorf("Expected eligible %v, got %v", tc.eligible, result.Eligible)
+			}
+			if len(result.Issues) != tc.issues {
+				t.Errorf("Expected %d issues, got %v", tc.issues, result.Issues)
+			}
+		})
+	}
+}
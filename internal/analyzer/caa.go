@@ -0,0 +1,270 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+const (
+	// caaCriticalFlag is the issuer critical bit of the CAA flags octet
+	caaCriticalFlag = 128
+	// caaMaxAuthorizedCAs is the number of CAs above which a policy is
+	// reported as overly permissive
+	caaMaxAuthorizedCAs = 3
+)
+
+// caaIssuers maps CAA issuer domains to the certificate authority they identify
+var caaIssuers = map[string]string{
+	"letsencrypt.org":      "Let's Encrypt",
+	"pki.goog":             "Google Trust Services",
+	"digicert.com":         "DigiCert",
+	"www.digicert.com":     "DigiCert",
+	"symantec.com":         "DigiCert",
+	"geotrust.com":         "DigiCert",
+	"rapidssl.com":         "DigiCert",
+	"thawte.com":           "DigiCert",
+	"sectigo.com":          "Sectigo",
+	"comodoca.com":         "Sectigo",
+	"comodo.com":           "Sectigo",
+	"usertrust.com":        "Sectigo",
+	"trust-provider.com":   "Sectigo",
+	"amazon.com":           "Amazon",
+	"amazontrust.com":      "Amazon",
+	"awstrust.com":         "Amazon",
+	"amazonaws.com":        "Amazon",
+	"globalsign.com":       "GlobalSign",
+	"godaddy.com":          "GoDaddy",
+	"starfieldtech.com":    "GoDaddy",
+	"entrust.net":          "Entrust",
+	"affirmtrust.com":      "Entrust",
+	"buypass.com":          "Buypass",
+	"buypass.no":           "Buypass",
+	"ssl.com":              "SSL.com",
+	"zerossl.com":          "ZeroSSL",
+	"harica.gr":            "HARICA",
+	"certum.pl":            "Certum",
+	"certum.eu":            "Certum",
+	"identrust.com":        "IdenTrust",
+	"trustwave.com":        "Trustwave",
+	"secomtrust.net":       "SECOM",
+	"microsoft.com":        "Microsoft",
+	"actalis.it":           "Actalis",
+	"telia.com":            "Telia",
+	"quovadisglobal.com":   "DigiCert",
+	"swisssign.com":        "SwissSign",
+	"certainly.com":        "Certainly",
+	"firmaprofesional.com": "Firmaprofesional",
+}
+
+// AnalyzeCAA finds the CAA record set that governs a name by climbing the DNS
+// tree (RFC 8659 section 3) and works out which CAs may issue for it
+func AnalyzeCAA(ctx context.Context, resolver Resolver, name string) *models.CAAResult {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	result := &models.CAAResult{Name: name}
+
+	var records []models.DNSResponse
+	for current := name; current != ""; current = parentDomain(current) {
+		responses, err := resolver.Lookup(ctx, current, "CAA")
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("error looking up CAA records for %s: %v", current, err))
+			return result
+		}
+
+		for _, response := range responses {
+			if response.RecordType == "CAA" {
+				records = append(records, response)
+			}
+		}
+		if len(records) > 0 {
+			result.PolicyDomain = current
+			break
+		}
+
+		// Stop before querying the top-level domain on its own
+		if !strings.Contains(parentDomain(current), ".") {
+			break
+		}
+	}
+
+	if len(records) == 0 {
+		result.AnyCA = true
+		result.Warnings = append(result.Warnings, "no CAA records found, any certificate authority may issue certificates for this name")
+		return result
+	}
+
+	for _, record := range records {
+		result.Records = append(result.Records, record.Value)
+
+		flags, tag, value, err := parseCAAValue(record.Value)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+
+		switch tag {
+		case "issue":
+			result.Issue = append(result.Issue, parseCAAIssuer(value, flags))
+		case "issuewild":
+			result.IssueWild = append(result.IssueWild, parseCAAIssuer(value, flags))
+		case "iodef":
+			result.IODEF = append(result.IODEF, value)
+		case "issuemail", "issuevmc", "contactemail", "contactphone":
+			// Known properties that don't govern TLS certificate issuance
+		default:
+			if flags&caaCriticalFlag != 0 {
+				result.UnknownCritical = append(result.UnknownCritical, tag)
+			}
+		}
+	}
+
+	result.AuthorizedCAs = authorizedCAs(result.Issue)
+	if len(result.IssueWild) > 0 {
+		result.AuthorizedWildcardCAs = authorizedCAs(result.IssueWild)
+	} else {
+		// Without issuewild, issue properties also govern wildcard certificates
+		result.AuthorizedWildcardCAs = result.AuthorizedCAs
+	}
+
+	if len(result.UnknownCritical) > 0 {
+		result.AuthorizedCAs = nil
+		result.AuthorizedWildcardCAs = nil
+		result.Warnings = append(result.Warnings, fmt.Sprintf("unknown critical CAA properties (%s) forbid all issuance", strings.Join(result.UnknownCritical, ", ")))
+	}
+
+	if len(result.Issue) == 0 && len(result.IssueWild) == 0 {
+		result.AnyCA = true
+		result.Warnings = append(result.Warnings, "CAA records have no issue or issuewild property, any certificate authority may issue")
+	} else if len(result.Issue) == 0 {
+		result.AnyCA = true
+		result.Warnings = append(result.Warnings, "CAA records have no issue property, any certificate authority may issue non-wildcard certificates")
+	}
+
+	if len(result.AuthorizedCAs) > caaMaxAuthorizedCAs {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d certificate authorities are authorized, consider restricting issuance", len(result.AuthorizedCAs)))
+	}
+	if len(result.IODEF) == 0 {
+		result.Warnings = append(result.Warnings, "no iodef property, CAs have no contact for reporting refused requests")
+	}
+
+	return result
+}
+
+// parseCAAValue splits a CAA value in `flags tag "value"` form
+func parseCAAValue(raw string) (int, string, string, error) {
+	fields := strings.SplitN(strings.TrimSpace(raw), " ", 3)
+	if len(fields) != 3 {
+		return 0, "", "", fmt.Errorf("malformed CAA record %q", raw)
+	}
+
+	flags, err := strconv.Atoi(fields[0])
+	if err != nil || flags < 0 || flags > 255 {
+		return 0, "", "", fmt.Errorf("malformed CAA flags in %q", raw)
+	}
+
+	value := strings.TrimSpace(fields[2])
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "\""), "\"")
+	return flags, strings.ToLower(fields[1]), value, nil
+}
+
+// parseCAAIssuer parses an issue or issuewild value: an optional issuer domain
+// followed by semicolon-separated key=value parameters
+func parseCAAIssuer(value string, flags int) models.CAAIssuer {
+	parts := strings.Split(value, ";")
+	issuer := models.CAAIssuer{
+		Domain:   strings.ToLower(strings.TrimSpace(parts[0])),
+		Critical: flags&caaCriticalFlag != 0,
+	}
+	issuer.Name = caaIssuers[issuer.Domain]
+
+	for _, part := range parts[1:] {
+		key, paramValue, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		paramValue = strings.TrimSpace(paramValue)
+
+		switch key {
+		case "accounturi":
+			issuer.AccountURI = paramValue
+		case "validationmethods":
+			for _, method := range strings.Split(paramValue, ",") {
+				if method = strings.TrimSpace(method); method != "" {
+					issuer.ValidationMethods = append(issuer.ValidationMethods, method)
+				}
+			}
+		default:
+			if issuer.Parameters == nil {
+				issuer.Parameters = make(map[string]string)
+			}
+			issuer.Parameters[key] = paramValue
+		}
+	}
+
+	return issuer
+}
+
+// authorizedCAs returns the distinct CAs named by a set of issue properties.
+// An empty issuer domain (issue ";") authorizes nobody.
+func authorizedCAs(issuers []models.CAAIssuer) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, issuer := range issuers {
+		if issuer.Domain == "" {
+			continue
+		}
+		name := issuer.Name
+		if name == "" {
+			name = issuer.Domain
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// caaFindings reports a name that no CAA record set governs, at the name or
+// any of its parents, and a record set that leaves out the issue property.
+// Failed lookups report nothing.
+func caaFindings(result *models.CAAResult) []models.Finding {
+	if len(result.Errors) > 0 {
+		return nil
+	}
+	if len(result.Records) == 0 {
+		return []models.Finding{{
+			ID:          "caa-missing",
+			Severity:    models.SeverityLow,
+			Description: "no CAA records cover the domain, so any certificate authority may issue for it",
+			Evidence:    []string{fmt.Sprintf("no CAA records at %s or its parent domains", result.Name)},
+			Remediation: "Publish CAA records naming the certificate authorities the domain uses (RFC 8659)",
+		}}
+	}
+	if len(result.Issue) == 0 {
+		var evidence []string
+		for _, record := range result.Records {
+			evidence = append(evidence, fmt.Sprintf("%s: %s", result.PolicyDomain, record))
+		}
+		return []models.Finding{{
+			ID:          "caa-no-issue",
+			Severity:    models.SeverityLow,
+			Description: "the CAA records have no issue property, so any certificate authority may issue non-wildcard certificates",
+			Evidence:    evidence,
+			Remediation: "Add an issue property naming the certificate authorities the domain uses",
+		}}
+	}
+	return nil
+}
+
+// parentDomain strips the leftmost label of a name
+func parentDomain(name string) string {
+	_, parent, _ := strings.Cut(name, ".")
+	return parent
+}
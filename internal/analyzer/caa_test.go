@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeCAA(t *testing.T) {
+	testCases := []struct {
+		name            string
+		scanned         string
+		records         fakeResolver
+		policyDomain    string
+		anyCA           bool
+		authorized      []string
+		authorizedWild  []string
+		validation      []string
+		accountURI      string
+		iodef           []string
+		unknownCritical []string
+		finding         string
+	}{
+		{
+			name:    "No CAA anywhere",
+			scanned: "www.example.com",
+			records: fakeResolver{},
+			anyCA:   true,
+			finding: "caa-missing",
+		},
+		{
+			name:    "Only issuewild and iodef",
+			scanned: "example.com",
+			records: fakeResolver{
+				"example.com CAA": {`0 issuewild "letsencrypt.org"`, `0 iodef "mailto:security@example.com"`},
+			},
+			policyDomain:   "example.com",
+			anyCA:          true,
+			authorizedWild: []string{"Let's Encrypt"},
+			iodef:          []string{"mailto:security@example.com"},
+			finding:        "caa-no-issue",
+		},
+		{
+			name:    "Tree climb to the parent",
+			scanned: "www.shop.example.com",
+			records: fakeResolver{
+				"example.com CAA": {
+					`0 issue "letsencrypt.org; validationmethods=dns-01,http-01; accounturi=https://acme-v02.api.letsencrypt.org/acme/acct/1"`,
+					`0 issuewild ";"`,
+					`0 iodef "mailto:security@example.com"`,
+				},
+			},
+			policyDomain: "example.com",
+			authorized:   []string{"Let's Encrypt"},
+			validation:   []string{"dns-01", "http-01"},
+			accountURI:   "https://acme-v02.api.letsencrypt.org/acme/acct/1",
+			iodef:        []string{"mailto:security@example.com"},
+		},
+		{
+			name:    "Closest record set wins",
+			scanned: "api.example.com",
+			records: fakeResolver{
+				"api.example.com CAA": {`0 issue "pki.goog"`, `0 issue "example-ca.test"`},
+				"example.com CAA":     {`0 issue "letsencrypt.org"`},
+			},
+			policyDomain:   "api.example.com",
+			authorized:     []string{"Google Trust Services", "example-ca.test"},
+			authorizedWild: []string{"Google Trust Services", "example-ca.test"},
+		},
+		{
+			name:    "Unknown critical property blocks issuance",
+			scanned: "example.com",
+			records: fakeResolver{
+				"example.com CAA": {`0 issue "digicert.com"`, `128 tbs "unknown"`},
+			},
+			policyDomain:    "example.com",
+			unknownCritical: []string{"tbs"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := AnalyzeCAA(context.Background(), tc.records, tc.scanned)
+
+			if result.PolicyDomain != tc.policyDomain {
+				t.Errorf("Expected policy domain %q, got %q", tc.policyDomain, result.PolicyDomain)
+			}
+			if result.AnyCA != tc.anyCA {
+				t.Errorf("Expected anyCa %v, got %v", tc.anyCA, result.AnyCA)
+			}
+			if !reflect.DeepEqual(result.AuthorizedCAs, tc.authorized) {
+				t.Errorf("Expected authorized CAs %v, got %v", tc.authorized, result.AuthorizedCAs)
+			}
+			if !reflect.DeepEqual(result.AuthorizedWildcardCAs, tc.authorizedWild) {
+				t.Errorf("Expected wildcard CAs %v, got %v", tc.authorizedWild, result.AuthorizedWildcardCAs)
+			}
+			if !reflect.DeepEqual(result.IODEF, tc.iodef) {
+				t.Errorf("Expected iodef %v, got %v", tc.iodef, result.IODEF)
+			}
+			if !reflect.DeepEqual(result.UnknownCritical, tc.unknownCritical) {
+				t.Errorf("Expected unknown critical %v, got %v", tc.unknownCritical, result.UnknownCritical)
+			}
+			var ids []string
+			for _, finding := range caaFindings(result) {
+				ids = append(ids, finding.ID)
+			}
+			if strings.Join(ids, ",") != tc.finding {
+				t.Errorf("Expected finding %q, got %v", tc.finding, ids)
+			}
+			if tc.validation != nil || tc.accountURI != "" {
+				if len(result.Issue) == 0 {
+					t.Fatalf("Expected issue properties, got none")
+				}
+				if !reflect.DeepEqual(result.Issue[0].ValidationMethods, tc.validation) || result.Issue[0].AccountURI != tc.accountURI {
+					t.Errorf("Expected parameters %v %q, got %+v", tc.validation, tc.accountURI, result.Issue[0])
+				}
+			}
+		})
+	}
+}
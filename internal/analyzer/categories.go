@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// ApplyTaxonomy resolves the category of every signature against the
+// taxonomy and fills in its canonical name, ID and path. Signatures with an
+// unknown category keep their category text without a path and are reported
+// together in the returned error.
+func ApplyTaxonomy(signatures models.SignatureFile, taxonomy *models.Taxonomy) (models.SignatureFile, error) {
+	resolved := models.SignatureFile{Signatures: make([]models.Signature, 0, len(signatures.Signatures))}
+
+	var unknown []string
+	for _, sig := range signatures.Signatures {
+		if category, ok := taxonomy.Resolve(sig.Category); ok {
+			sig.Category = category.Name
+			sig.CategoryID = category.ID
+			sig.CategoryPath = taxonomy.Path(category.ID)
+		} else {
+			unknown = append(unknown, fmt.Sprintf("signature %s: unknown category %q", sig.Name, sig.Category))
+		}
+		resolved.Signatures = append(resolved.Signatures, sig)
+	}
+
+	if len(unknown) > 0 {
+		return resolved, fmt.Errorf("signatures with unknown categories:\n  %s", strings.Join(unknown, "\n  "))
+	}
+	return resolved, nil
+}
+
+// CategoryFilter selects signatures by their place in the taxonomy. A
+// category matches itself and everything below it.
+type CategoryFilter struct {
+	// Include keeps only signatures within one of these category IDs
+	Include []string
+	// Exclude drops signatures within any of these category IDs
+	Exclude []string
+}
+
+// NewCategoryFilter resolves category IDs, names or aliases given on the
+// command line
+func NewCategoryFilter(taxonomy *models.Taxonomy, include, exclude []string) (CategoryFilter, error) {
+	var filter CategoryFilter
+	for _, name := range include {
+		category, ok := taxonomy.Resolve(name)
+		if !ok {
+			return filter, fmt.Errorf("unknown category %q", name)
+		}
+		filter.Include = append(filter.Include, category.ID)
+	}
+	for _, name := range exclude {
+		category, ok := taxonomy.Resolve(name)
+		if !ok {
+			return filter, fmt.Errorf("unknown category %q", name)
+		}
+		filter.Exclude = append(filter.Exclude, category.ID)
+	}
+	return filter, nil
+}
+
+// Empty reports whether the filter lets every signature through
+func (f CategoryFilter) Empty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Allows reports whether a category path passes the filter. Signatures
+// without a path only pass when nothing is explicitly included.
+func (f CategoryFilter) Allows(path []string) bool {
+	for _, id := range f.Exclude {
+		if containsString(path, id) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, id := range f.Include {
+		if containsString(path, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterSignatures keeps the signatures whose category passes the filter.
+// Implies and excludes entries naming dropped signatures are removed too, so
+// a filtered set never infers technologies outside the filter.
+func FilterSignatures(signatures models.SignatureFile, filter CategoryFilter) models.SignatureFile {
+	if filter.Empty() {
+		return signatures
+	}
+
+	kept := make(map[string]bool)
+	filtered := models.SignatureFile{}
+	for _, sig := range signatures.Signatures {
+		if filter.Allows(sig.CategoryPath) {
+			kept[sig.Name] = true
+			filtered.Signatures = append(filtered.Signatures, sig)
+		}
+	}
+
+	for i := range filtered.Signatures {
+		sig := &filtered.Signatures[i]
+		sig.Implies = keepNames(sig.Implies, kept)
+		sig.Excludes = keepNames(sig.Excludes, kept)
+	}
+	return filtered
+}
+
+// keepNames returns the names present in kept
+func keepNames(names []string, kept map[string]bool) []string {
+	var result []string
+	for _, name := range names {
+		if kept[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
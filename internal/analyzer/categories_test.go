@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+func TestCategoryFiltering(t *testing.T) {
+	taxonomy, err := models.NewTaxonomy(models.CategoryFile{Categories: []models.Category{
+		{ID: "email", Name: "Email"},
+		{ID: "email-hosting", Name: "Email Hosting", Parent: "email", Aliases: []string{"Email & Collaboration"}},
+		{ID: "email-security", Name: "Email Security", Parent: "email"},
+		{ID: "identity", Name: "Identity Management"},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	signatures, err := ApplyTaxonomy(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Suite", Category: "Email & Collaboration", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "suite.example"}}, Implies: []string{"Directory"}},
+		{Name: "Filter", Category: "email-security", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "filter.example"}}},
+		{Name: "Directory", Category: "Identity Management"},
+		{Name: "Stray", Category: "Miscellaneous", RecordTypes: []string{"TXT"}, Patterns: []models.Pattern{{Prefix: "stray="}}},
+	}}, taxonomy)
+	if err == nil || !strings.Contains(err.Error(), `signature Stray: unknown category "Miscellaneous"`) {
+		t.Fatalf("Expected the unknown category to be reported, got %v", err)
+	}
+
+	records := []models.DNSResponse{
+		{RecordType: "MX", Value: "10 mx.suite.example."},
+		{RecordType: "MX", Value: "20 mx.filter.example."},
+		{RecordType: "TXT", Value: "stray=1"},
+	}
+
+	testCases := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "no filter",
+			expected: []string{"Suite email/email-hosting", "Filter email/email-security", "Stray ", "Directory identity"},
+		},
+		{
+			name:     "include a parent category",
+			include:  []string{"Email"},
+			expected: []string{"Suite email/email-hosting", "Filter email/email-security"},
+		},
+		{
+			name:     "exclude a subcategory by alias",
+			exclude:  []string{"email & collaboration"},
+			expected: []string{"Filter email/email-security", "Stray "},
+		},
+		{
+			name:     "include and exclude",
+			include:  []string{"email", "identity"},
+			exclude:  []string{"email-security"},
+			expected: []string{"Suite email/email-hosting", "Directory identity"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := NewCategoryFilter(taxonomy, tc.include, tc.exclude)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			matcher, err := NewMatcher(FilterSignatures(signatures, filter))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			detected := []string{}
+			for _, tech := range matcher.Detect(records) {
+				detected = append(detected, tech.Name+" "+strings.Join(tech.CategoryPath, "/"))
+			}
+			if !reflect.DeepEqual(detected, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, detected)
+			}
+		})
+	}
+
+	if _, err := NewCategoryFilter(taxonomy, []string{"unknown"}, nil); err == nil {
+		t.Errorf("Expected an unknown category in the filter to be rejected")
+	}
+	if signatures.Signatures[0].Category != "Email Hosting" {
+		t.Errorf("Expected the alias to be replaced by the category name, got %q", signatures.Signatures[0].Category)
+	}
+}
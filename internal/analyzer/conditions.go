@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// compiledCondition is a signature condition with its patterns compiled
+type compiledCondition struct {
+	all []*compiledCondition
+	any []*compiledCondition
+	min int
+	not *compiledCondition
+
+	match       *compiledPattern
+	recordTypes []string
+	minCount    int
+}
+
+// conditionMatch is a record that satisfied a match condition
+type conditionMatch struct {
+	record  models.DNSResponse
+	pattern compiledPattern
+	match   []string
+}
+
+// compileCondition validates and compiles a condition. Match conditions
+// without record types inherit the signature's.
+func compileCondition(condition models.Condition, recordTypes []string) (*compiledCondition, error) {
+	set := 0
+	for _, isSet := range []bool{len(condition.All) > 0, len(condition.Any) > 0, condition.Not != nil, condition.Match != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("condition must set exactly one of all, any, not or match")
+	}
+
+	compiled := &compiledCondition{}
+	switch {
+	case len(condition.All) > 0:
+		for _, sub := range condition.All {
+			child, err := compileCondition(sub, recordTypes)
+			if err != nil {
+				return nil, err
+			}
+			compiled.all = append(compiled.all, child)
+		}
+
+	case len(condition.Any) > 0:
+		compiled.min = condition.Min
+		if compiled.min <= 0 {
+			compiled.min = 1
+		}
+		if compiled.min > len(condition.Any) {
+			return nil, fmt.Errorf("condition requires %d of only %d alternatives", compiled.min, len(condition.Any))
+		}
+		for _, sub := range condition.Any {
+			child, err := compileCondition(sub, recordTypes)
+			if err != nil {
+				return nil, err
+			}
+			compiled.any = append(compiled.any, child)
+		}
+
+	case condition.Not != nil:
+		child, err := compileCondition(*condition.Not, recordTypes)
+		if err != nil {
+			return nil, err
+		}
+		compiled.not = child
+
+	default:
+		pattern, err := compilePattern(*condition.Match)
+		if err != nil {
+			return nil, err
+		}
+		compiled.match = &pattern
+		compiled.recordTypes = condition.RecordTypes
+		if len(compiled.recordTypes) == 0 {
+			compiled.recordTypes = recordTypes
+		}
+		compiled.minCount = condition.MinCount
+		if compiled.minCount <= 0 {
+			compiled.minCount = 1
+		}
+	}
+
+	return compiled, nil
+}
+
+// evaluate reports whether the condition holds for a domain's records and
+// which records made it hold. Records under a negation are never returned.
+func (c *compiledCondition) evaluate(records []models.DNSResponse) (bool, []conditionMatch) {
+	switch {
+	case c.all != nil:
+		var matches []conditionMatch
+		for _, child := range c.all {
+			holds, childMatches := child.evaluate(records)
+			if !holds {
+				return false, nil
+			}
+			matches = append(matches, childMatches...)
+		}
+		return true, matches
+
+	case c.any != nil:
+		held := 0
+		var matches []conditionMatch
+		for _, child := range c.any {
+			if holds, childMatches := child.evaluate(records); holds {
+				held++
+				matches = append(matches, childMatches...)
+			}
+		}
+		if held < c.min {
+			return false, nil
+		}
+		return true, matches
+
+	case c.not != nil:
+		holds, _ := c.not.evaluate(records)
+		return !holds, nil
+
+	case c.match != nil:
+		var matches []conditionMatch
+		for _, record := range records {
+			if !containsString(c.recordTypes, record.RecordType) && !containsString(c.recordTypes, "*") {
+				continue
+			}
+			if match := c.match.match(record); match != nil {
+				matches = append(matches, conditionMatch{record: record, pattern: *c.match, match: match})
+			}
+		}
+		if len(matches) < c.minCount {
+			return false, nil
+		}
+		return true, matches
+	}
+
+	return false, nil
+}
@@ -0,0 +1,348 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// DelegationProber queries parent and child nameservers directly.
+// *dns.Client satisfies it.
+type DelegationProber interface {
+	QueryReferral(ctx context.Context, zone, server string) ([]string, map[string][]string, error)
+	QuerySOA(ctx context.Context, zone, server string) (uint32, bool, error)
+}
+
+// AnalyzeDelegation reads the zone's delegation from its parent zone servers
+// and checks it against the zone's own NS records and the nameservers' answers
+func AnalyzeDelegation(ctx context.Context, resolver Resolver, prober DelegationProber, domain string, records []models.DNSResponse) *models.DelegationResult {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	result := &models.DelegationResult{Zone: domain}
+
+	// The zone's own view of its nameservers
+	for _, record := range records {
+		if record.RecordType == "NS" {
+			result.ChildNS = appendUnique(result.ChildNS, strings.TrimSuffix(strings.ToLower(record.Value), "."))
+		}
+	}
+	if len(result.ChildNS) == 0 {
+		responses, err := resolver.Lookup(ctx, domain, "NS")
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("error looking up NS records for %s: %v", domain, err))
+		}
+		for _, response := range responses {
+			if response.RecordType == "NS" {
+				result.ChildNS = appendUnique(result.ChildNS, strings.TrimSuffix(strings.ToLower(response.Value), "."))
+			}
+		}
+	}
+	sort.Strings(result.ChildNS)
+
+	// The parent's view: find the closest enclosing zone and ask its servers
+	if err := readParentDelegation(ctx, resolver, prober, result); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	if len(result.ParentNS) > 0 && len(result.ChildNS) > 0 {
+		onlyParent := difference(result.ParentNS, result.ChildNS)
+		onlyChild := difference(result.ChildNS, result.ParentNS)
+		if len(onlyParent) > 0 || len(onlyChild) > 0 {
+			var evidence []string
+			for _, host := range onlyParent {
+				evidence = append(evidence, fmt.Sprintf("%s is only listed in the %s zone", host, result.ParentZone))
+			}
+			for _, host := range onlyChild {
+				evidence = append(evidence, fmt.Sprintf("%s is only listed in the %s zone", host, domain))
+			}
+			result.Findings = append(result.Findings, models.Finding{
+				ID:          "delegation-ns-mismatch",
+				Severity:    models.SeverityMedium,
+				Description: "the NS records at the parent zone and in the zone itself differ",
+				Evidence:    evidence,
+				Remediation: "Update the delegation at the registrar or the zone's NS records so both list the same nameservers",
+			})
+		}
+	}
+
+	hosts := append([]string(nil), result.ParentNS...)
+	for _, host := range result.ChildNS {
+		hosts = appendUnique(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	if len(hosts) == 1 {
+		result.Findings = append(result.Findings, models.Finding{
+			ID:          "delegation-single-nameserver",
+			Severity:    models.SeverityMedium,
+			Description: "the zone is served by a single nameserver",
+			Evidence:    hosts,
+			Remediation: "Add at least one more nameserver on a separate network (RFC 1034 section 4.1)",
+		})
+	}
+
+	checkGlue(ctx, resolver, result)
+	probeDelegatedServers(ctx, resolver, prober, hosts, result)
+	checkServerAnswers(result)
+	checkNameserverDiversity(hosts, result)
+
+	return result
+}
+
+// readParentDelegation finds the parent zone of result.Zone and fills in the
+// NS set and glue its servers hand out
+func readParentDelegation(ctx context.Context, resolver Resolver, prober DelegationProber, result *models.DelegationResult) error {
+	var parentServers []string
+	for parent := parentDomain(result.Zone); parent != ""; parent = parentDomain(parent) {
+		responses, err := resolver.Lookup(ctx, parent, "NS")
+		if err != nil {
+			return fmt.Errorf("error looking up NS records for %s: %v", parent, err)
+		}
+		for _, response := range responses {
+			if response.RecordType == "NS" {
+				parentServers = append(parentServers, strings.TrimSuffix(strings.ToLower(response.Value), "."))
+			}
+		}
+		if len(parentServers) > 0 {
+			result.ParentZone = parent
+			break
+		}
+	}
+	if len(parentServers) == 0 {
+		return fmt.Errorf("no parent zone found for %s", result.Zone)
+	}
+	sort.Strings(parentServers)
+
+	var lastErr error
+	for _, host := range parentServers {
+		for _, address := range resolveHost(ctx, resolver, host) {
+			server := net.JoinHostPort(address, nameserverPort)
+			nameservers, glue, err := prober.QueryReferral(ctx, result.Zone, server)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			result.ParentServer = host
+			for _, nameserver := range nameservers {
+				result.ParentNS = appendUnique(result.ParentNS, nameserver)
+			}
+			sort.Strings(result.ParentNS)
+			if len(glue) > 0 {
+				result.Glue = glue
+			}
+			return nil
+		}
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("no %s server returned a delegation for %s: %v", result.ParentZone, result.Zone, lastErr)
+	}
+	return fmt.Errorf("could not resolve any %s server", result.ParentZone)
+}
+
+// checkGlue compares the glue addresses handed out by the parent with the
+// addresses the nameserver names actually resolve to
+func checkGlue(ctx context.Context, resolver Resolver, result *models.DelegationResult) {
+	hosts := make([]string, 0, len(result.Glue))
+	for host := range result.Glue {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var evidence []string
+	for _, host := range hosts {
+		resolved := resolveHost(ctx, resolver, host)
+		if len(resolved) == 0 {
+			continue
+		}
+		glue := append([]string(nil), result.Glue[host]...)
+		sort.Strings(glue)
+		if len(difference(glue, resolved)) > 0 || len(difference(resolved, glue)) > 0 {
+			evidence = append(evidence, fmt.Sprintf("%s glue %s, resolves to %s", host, strings.Join(glue, ","), strings.Join(resolved, ",")))
+		}
+	}
+
+	if len(evidence) > 0 {
+		result.Findings = append(result.Findings, models.Finding{
+			ID:          "delegation-glue-mismatch",
+			Severity:    models.SeverityMedium,
+			Description: "glue records at the parent zone don't match the nameservers' addresses",
+			Evidence:    evidence,
+			Remediation: "Update the glue (host) records at the registrar to the nameservers' current addresses",
+		})
+	}
+}
+
+// probeDelegatedServers sends an SOA query to every address of every
+// nameserver, using glue where the parent provided it
+func probeDelegatedServers(ctx context.Context, resolver Resolver, prober DelegationProber, hosts []string, result *models.DelegationResult) {
+	var servers []models.DelegationServer
+	for _, host := range hosts {
+		addresses := result.Glue[host]
+		if len(addresses) == 0 {
+			addresses = resolveHost(ctx, resolver, host)
+		}
+		if len(addresses) == 0 {
+			servers = append(servers, models.DelegationServer{Host: host, Error: "nameserver name does not resolve"})
+			continue
+		}
+		for _, address := range addresses {
+			servers = append(servers, models.DelegationServer{Host: host, Address: address})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range servers {
+		if servers[i].Address == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(server *models.DelegationServer) {
+			defer wg.Done()
+
+			serial, authoritative, err := prober.QuerySOA(ctx, result.Zone, net.JoinHostPort(server.Address, nameserverPort))
+			if err != nil {
+				server.Error = err.Error()
+				return
+			}
+			server.Serial = serial
+			server.Authoritative = authoritative
+		}(&servers[i])
+	}
+	wg.Wait()
+
+	result.Servers = servers
+}
+
+// checkServerAnswers reports lame servers and SOA serial drift
+func checkServerAnswers(result *models.DelegationResult) {
+	var lame []string
+	serials := make(map[uint32][]string)
+	for _, server := range result.Servers {
+		name := server.Host
+		if server.Address != "" {
+			name = fmt.Sprintf("%s (%s)", server.Host, server.Address)
+		}
+
+		switch {
+		case server.Error != "":
+			lame = append(lame, fmt.Sprintf("%s: %s", name, server.Error))
+		case !server.Authoritative:
+			lame = append(lame, fmt.Sprintf("%s: answer is not authoritative", name))
+		default:
+			serials[server.Serial] = append(serials[server.Serial], name)
+		}
+	}
+
+	if len(lame) > 0 {
+		result.Findings = append(result.Findings, models.Finding{
+			ID:          "delegation-lame",
+			Severity:    models.SeverityHigh,
+			Description: "some delegated nameservers don't answer authoritatively for the zone",
+			Evidence:    lame,
+			Remediation: "Configure the zone on every listed nameserver or remove the lame servers from the delegation",
+		})
+	}
+
+	if len(serials) > 1 {
+		values := make([]uint32, 0, len(serials))
+		for serial := range serials {
+			values = append(values, serial)
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+		var evidence []string
+		for _, serial := range values {
+			evidence = append(evidence, fmt.Sprintf("serial %d: %s", serial, strings.Join(serials[serial], ", ")))
+		}
+		result.Findings = append(result.Findings, models.Finding{
+			ID:          "delegation-serial-drift",
+			Severity:    models.SeverityMedium,
+			Description: "nameservers serve different versions of the zone",
+			Evidence:    evidence,
+			Remediation: "Check zone transfers (NOTIFY/IXFR) between the primary and the secondaries",
+		})
+	}
+}
+
+// checkNameserverDiversity reports nameservers that share a single provider
+// or a single /24 network
+func checkNameserverDiversity(hosts []string, result *models.DelegationResult) {
+	if len(hosts) < 2 {
+		return
+	}
+
+	providers := make(map[string]bool)
+	for _, host := range hosts {
+		provider, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			provider = host
+		}
+		providers[provider] = true
+	}
+	if len(providers) == 1 {
+		result.Findings = append(result.Findings, models.Finding{
+			ID:          "delegation-single-provider",
+			Severity:    models.SeverityLow,
+			Description: "all nameservers belong to a single DNS provider",
+			Evidence:    hosts,
+			Remediation: "Consider a secondary DNS provider so an outage at one provider doesn't take the zone offline",
+		})
+	}
+
+	networks := make(map[string]bool)
+	var addresses []string
+	for _, server := range result.Servers {
+		ip := net.ParseIP(server.Address).To4()
+		if ip == nil {
+			continue
+		}
+		networks[ip.Mask(net.CIDRMask(24, 32)).String()] = true
+		addresses = appendUnique(addresses, server.Address)
+	}
+	// A single address says nothing about how the servers are spread out
+	if len(addresses) >= 2 && len(networks) == 1 {
+		result.Findings = append(result.Findings, models.Finding{
+			ID:          "delegation-single-network",
+			Severity:    models.SeverityMedium,
+			Description: "all IPv4 nameserver addresses are in the same /24 network",
+			Evidence:    addresses,
+			Remediation: "Place nameservers in different networks so a single routing problem can't make the zone unreachable",
+		})
+	}
+}
+
+// resolveHost returns the sorted IPv4 and IPv6 addresses of a host
+func resolveHost(ctx context.Context, resolver Resolver, host string) []string {
+	var addresses []string
+	for _, recordType := range []string{"A", "AAAA"} {
+		responses, err := resolver.Lookup(ctx, host, recordType)
+		if err != nil {
+			continue
+		}
+		for _, response := range responses {
+			if response.RecordType == recordType {
+				addresses = appendUnique(addresses, response.Value)
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// difference returns the items of a that are not in b
+func difference(a, b []string) []string {
+	var result []string
+	for _, item := range a {
+		if !containsString(b, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// soaAnswer is a canned answer to an SOA query
+type soaAnswer struct {
+	serial        uint32
+	authoritative bool
+}
+
+// fakeDelegationProber answers referral and SOA queries from static tables
+// keyed by server address
+type fakeDelegationProber struct {
+	referrals map[string][]string
+	glue      map[string][]string
+	soa       map[string]soaAnswer
+}
+
+func (f fakeDelegationProber) QueryReferral(ctx context.Context, zone, server string) ([]string, map[string][]string, error) {
+	nameservers, ok := f.referrals[server]
+	if !ok {
+		return nil, nil, fmt.Errorf("timeout")
+	}
+	return nameservers, f.glue, nil
+}
+
+func (f fakeDelegationProber) QuerySOA(ctx context.Context, zone, server string) (uint32, bool, error) {
+	answer, ok := f.soa[server]
+	if !ok {
+		return 0, false, fmt.Errorf("timeout")
+	}
+	return answer.serial, answer.authoritative, nil
+}
+
+func TestAnalyzeDelegation(t *testing.T) {
+	parent := fakeResolver{
+		"com NS":               {"a.gtld-servers.net."},
+		"a.gtld-servers.net A": {"192.5.6.30"},
+	}
+
+	testCases := []struct {
+		name     string
+		resolver fakeResolver
+		prober   fakeDelegationProber
+		records  []string
+		parentNS []string
+		findings []string
+	}{
+		{
+			name: "Healthy delegation",
+			resolver: fakeResolver{
+				"ns1.example.net A": {"192.0.2.1"},
+				"ns2.example.org A": {"198.51.100.1"},
+			},
+			prober: fakeDelegationProber{
+				referrals: map[string][]string{"192.5.6.30:53": {"ns1.example.net", "ns2.example.org"}},
+				soa: map[string]soaAnswer{
+					"192.0.2.1:53":    {2024010101, true},
+					"198.51.100.1:53": {2024010101, true},
+				},
+			},
+			records:  []string{"ns1.example.net.", "ns2.example.org."},
+			parentNS: []string{"ns1.example.net", "ns2.example.org"},
+		},
+		{
+			name: "Mismatched NS, lame server and serial drift",
+			resolver: fakeResolver{
+				"ns1.example.net A": {"192.0.2.1"},
+				"ns2.example.net A": {"192.0.2.2"},
+				"ns3.example.net A": {"192.0.2.3"},
+			},
+			prober: fakeDelegationProber{
+				referrals: map[string][]string{"192.5.6.30:53": {"ns1.example.net", "ns2.example.net"}},
+				soa: map[string]soaAnswer{
+					"192.0.2.1:53": {2024010101, true},
+					"192.0.2.2:53": {2024010100, true},
+					"192.0.2.3:53": {0, false},
+				},
+			},
+			records:  []string{"ns1.example.net.", "ns3.example.net."},
+			parentNS: []string{"ns1.example.net", "ns2.example.net"},
+			findings: []string{"delegation-ns-mismatch", "delegation-lame", "delegation-serial-drift", "delegation-single-provider", "delegation-single-network"},
+		},
+		{
+			name: "One IPv4 address is not a single network",
+			resolver: fakeResolver{
+				"ns1.example.net A":    {"192.0.2.1"},
+				"ns2.example.org AAAA": {"2001:db8::1"},
+			},
+			prober: fakeDelegationProber{
+				referrals: map[string][]string{"192.5.6.30:53": {"ns1.example.net", "ns2.example.org"}},
+				soa: map[string]soaAnswer{
+					"192.0.2.1:53":     {2024010101, true},
+					"[2001:db8::1]:53": {2024010101, true},
+				},
+			},
+			records:  []string{"ns1.example.net.", "ns2.example.org."},
+			parentNS: []string{"ns1.example.net", "ns2.example.org"},
+		},
+		{
+			name: "Stale glue",
+			resolver: fakeResolver{
+				"ns1.example.com A": {"203.0.113.10"},
+				"ns2.example.org A": {"198.51.100.1"},
+			},
+			prober: fakeDelegationProber{
+				referrals: map[string][]string{"192.5.6.30:53": {"ns1.example.com", "ns2.example.org"}},
+				glue:      map[string][]string{"ns1.example.com": {"192.0.2.1"}},
+				soa: map[string]soaAnswer{
+					"192.0.2.1:53":    {1, true},
+					"198.51.100.1:53": {1, true},
+				},
+			},
+			records:  []string{"ns1.example.com.", "ns2.example.org."},
+			parentNS: []string{"ns1.example.com", "ns2.example.org"},
+			findings: []string{"delegation-glue-mismatch"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := fakeResolver{}
+			for key, values := range parent {
+				resolver[key] = values
+			}
+			for key, values := range tc.resolver {
+				resolver[key] = values
+			}
+
+			var records []models.DNSResponse
+			for _, value := range tc.records {
+				records = append(records, models.DNSResponse{Domain: "example.com.", RecordType: "NS", Value: value})
+			}
+
+			result := AnalyzeDelegation(context.Background(), resolver, tc.prober, "example.com.", records)
+
+			if len(result.Errors) > 0 {
+				t.Fatalf("Unexpected errors: %v", result.Errors)
+			}
+			if result.ParentZone != "com" {
+				t.Errorf("Expected parent zone com, got %q", result.ParentZone)
+			}
+			if strings.Join(result.ParentNS, ",") != strings.Join(tc.parentNS, ",") {
+				t.Errorf("Expected parent NS %v, got %v", tc.parentNS, result.ParentNS)
+			}
+
+			var ids []string
+			for _, finding := range result.Findings {
+				ids = append(ids, finding.ID)
+			}
+			sort.Strings(ids)
+			expected := append([]string(nil), tc.findings...)
+			sort.Strings(expected)
+			if strings.Join(ids, ",") != strings.Join(expected, ",") {
+				t.Errorf("Expected findings %v, got %v", expected, result.Findings)
+			}
+		})
+	}
+}
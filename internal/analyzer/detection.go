@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"regexp"
 	"strings"
@@ -9,62 +10,379 @@ import (
 	"github.com/Elite-Security-Systems/radar/internal/models"
 )
 
-// DetectTechnologies identifies technologies from DNS records using signatures
-func DetectTechnologies(records []models.DNSResponse, signatures models.SignatureFile) []models.DetectedTechnology {
-	var detectedTechnologies []models.DetectedTechnology
-	detectedMap := make(map[string]bool) // To avoid duplicates
+// Matcher holds a signature set compiled once and indexed by record type,
+// so detection only runs the patterns that apply to each record
+type Matcher struct {
+	signatures  []compiledSignature
+	byType      map[string][]int
+	wildcard    []int
+	conditional []int
+	names       map[string]int
+
+	// literals finds exact, prefix, suffix and CIDR patterns by lookup,
+	// one index per record field
+	literals map[string]*literalIndex
+}
+
+// compiledSignature is a signature with its patterns compiled
+type compiledSignature struct {
+	signature models.Signature
+	patterns  []compiledPattern
+	condition *compiledCondition
+}
+
+// defaultPatternWeights are used for patterns without an explicit weight.
+// Records that route traffic to a provider are strong evidence; TXT tokens
+// often outlive the service they once verified.
+var defaultPatternWeights = map[string]int{
+	"MX":    90,
+	"NS":    90,
+	"CNAME": 80,
+	"SRV":   80,
+	"HTTPS": 80,
+	"SVCB":  80,
+	"A":     60,
+	"AAAA":  60,
+	"TXT":   50,
+}
+
+// defaultPatternWeight applies to record types missing from defaultPatternWeights
+const defaultPatternWeight = 50
+
+// NewMatcher compiles every signature pattern. Invalid patterns are left out
+// and reported together in the returned error; the matcher is still usable
+// with the remaining patterns.
+func NewMatcher(signatures models.SignatureFile) (*Matcher, error) {
+	matcher := &Matcher{
+		byType: make(map[string][]int),
+		names:  make(map[string]int),
+		literals: map[string]*literalIndex{
+			models.FieldValue: newLiteralIndex(),
+			models.FieldName:  newLiteralIndex(),
+		},
+	}
+
+	var invalid []string
+	for _, sig := range signatures.Signatures {
+		index := len(matcher.signatures)
+		compiled := compiledSignature{signature: sig}
+		for _, pattern := range sig.Patterns {
+			compiledPattern, err := compilePattern(pattern)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("signature %s: %v", sig.Name, err))
+				continue
+			}
+			if compiledPattern.indexed() {
+				matcher.literals[fieldOf(compiledPattern)].add(compiledPattern, patternRef{signature: index, pattern: len(compiled.patterns)})
+			}
+			compiled.patterns = append(compiled.patterns, compiledPattern)
+		}
+
+		if sig.Condition != nil {
+			condition, err := compileCondition(*sig.Condition, sig.RecordTypes)
+			if err != nil {
+				// Without its condition the signature would over-match, so it
+				// gets a condition that never holds
+				invalid = append(invalid, fmt.Sprintf("signature %s: %v", sig.Name, err))
+				condition = &compiledCondition{any: []*compiledCondition{}, min: 1}
+			}
+			compiled.condition = condition
+			matcher.conditional = append(matcher.conditional, index)
+		}
+		matcher.signatures = append(matcher.signatures, compiled)
+		if _, exists := matcher.names[sig.Name]; !exists {
+			matcher.names[sig.Name] = index
+		}
+		for _, recordType := range sig.RecordTypes {
+			if recordType == "*" {
+				matcher.wildcard = append(matcher.wildcard, index)
+				continue
+			}
+			types := matcher.byType[recordType]
+			if len(types) == 0 || types[len(types)-1] != index {
+				matcher.byType[recordType] = append(types, index)
+			}
+		}
+	}
+
+	invalid = append(invalid, matcher.checkRelations()...)
+
+	if len(invalid) > 0 {
+		return matcher, fmt.Errorf("invalid signatures:\n  %s", strings.Join(invalid, "\n  "))
+	}
+	return matcher, nil
+}
+
+// Len returns the number of signatures in the matcher
+func (m *Matcher) Len() int {
+	return len(m.signatures)
+}
+
+// Has reports whether the matcher has a signature with the given name,
+// ignoring case
+func (m *Matcher) Has(name string) bool {
+	for known := range m.names {
+		if strings.EqualFold(known, name) {
+			return true
+		}
+	}
+	return false
+}
 
+// Detect identifies technologies from DNS records. Every record that matches
+// a signature is kept as evidence for its detection. Mutually exclusive
+// detections are resolved by confidence and implied technologies are added
+// as inferred detections.
+func (m *Matcher) Detect(records []models.DNSResponse) []models.DetectedTechnology {
+	detections := newDetectionSet()
+
+	// Conditions look at all records at once, so settle them first
+	conditionHolds := make(map[int]bool)
+	conditionEvidence := make(map[int][]conditionMatch)
+	for _, index := range m.conditional {
+		conditionHolds[index], conditionEvidence[index] = m.signatures[index].condition.evaluate(records)
+	}
+
+	patternMatched := make(map[int]bool)
 	for _, record := range records {
-		// Create normalized versions of the record value for more robust matching
-		normalizedValue := strings.TrimSuffix(record.Value, ".")
-		
-		// Check each signature against this record
-		for _, sig := range signatures.Signatures {
-			// Skip if the signature doesn't apply to this record type
-			if !containsString(sig.RecordTypes, record.RecordType) && !containsString(sig.RecordTypes, "*") {
+		// Literal patterns that can match this record, found by lookup
+		candidates := make(map[patternRef]bool)
+		m.literals[models.FieldValue].lookup(record.Value, candidates)
+		m.literals[models.FieldName].lookup(record.Owner(), candidates)
+
+		// Only the signatures for this record type, in signature file order
+		for _, index := range mergeIndexes(m.byType[record.RecordType], m.wildcard) {
+			sig := &m.signatures[index]
+			if sig.condition != nil && !conditionHolds[index] {
 				continue
 			}
 
-			// Check each pattern in the signature
-			for _, pattern := range sig.Patterns {
-				re, err := regexp.Compile(pattern)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Invalid regex pattern in signature %s: %s\n", sig.Name, err)
+			for i, pattern := range sig.patterns {
+				if pattern.indexed() && !candidates[patternRef{signature: index, pattern: i}] {
 					continue
 				}
-
-				// First try with the original value
-				matched := false
-				if re.MatchString(record.Value) {
-					matched = true
-				} else if normalizedValue != record.Value && re.MatchString(normalizedValue) {
-					// If that doesn't match, try with the normalized value
-					matched = true
-				}
-
-				if matched {
-					// Avoid duplicates
-					key := sig.Name
-					if _, exists := detectedMap[key]; !exists {
-						detectedMap[key] = true
-						detectedTechnologies = append(detectedTechnologies, models.DetectedTechnology{
-							Name:        sig.Name,
-							Category:    sig.Category,
-							Description: sig.Description,
-							Website:     sig.Website,
-							Evidence:    record.Value,
-							RecordType:  record.RecordType,
-						})
-					}
-					break // No need to check other patterns for this signature
+				if match := pattern.match(record); match != nil {
+					detections.add(sig.signature, newEvidence(record, pattern, match))
+					patternMatched[index] = true
+					break // One pattern per record is enough
 				}
 			}
 		}
 	}
 
+	for _, index := range m.conditional {
+		sig := &m.signatures[index]
+		if !conditionHolds[index] || (len(sig.patterns) > 0 && !patternMatched[index]) {
+			continue
+		}
+		matches := conditionEvidence[index]
+		for _, match := range matches {
+			detections.add(sig.signature, newEvidence(match.record, match.pattern, match.match))
+		}
+		if len(matches) == 0 {
+			// A condition made only of negations has no records to show, so
+			// the detection rests on the condition alone
+			detections.add(sig.signature, models.Evidence{})
+			detections.weighCondition(sig.signature.Name)
+		}
+	}
+
+	return m.resolveRelations(detections.technologies())
+}
+
+// negationWeight is the confidence of a detection that rests only on records
+// being absent
+const negationWeight = defaultPatternWeight
+
+// detectionSet collects detections and their evidence in detection order
+type detectionSet struct {
+	detected  []models.DetectedTechnology
+	positions map[string]int
+	seen      map[string]bool
+	// conditionOnly holds detections with no records as evidence
+	conditionOnly map[string]bool
+}
+
+// newDetectionSet creates an empty detection set
+func newDetectionSet() *detectionSet {
+	return &detectionSet{positions: make(map[string]int), seen: make(map[string]bool), conditionOnly: make(map[string]bool)}
+}
+
+// weighCondition marks a detection as resting on its condition alone
+func (d *detectionSet) weighCondition(name string) {
+	d.conditionOnly[name] = true
+}
+
+// add records evidence for a signature, creating its detection if needed.
+// Empty evidence creates the detection without adding to its evidence.
+func (d *detectionSet) add(sig models.Signature, evidence models.Evidence) {
+	if evidence.Pattern != "" {
+		key := sig.Name + "\x00" + evidence.Name + "\x00" + evidence.RecordType + "\x00" + evidence.Value
+		if d.seen[key] {
+			return
+		}
+		d.seen[key] = true
+	}
+
+	position, exists := d.positions[sig.Name]
+	if !exists {
+		position = len(d.detected)
+		d.positions[sig.Name] = position
+		d.detected = append(d.detected, newDetection(sig))
+	}
+	if evidence.Pattern == "" {
+		return
+	}
+
+	tech := &d.detected[position]
+	tech.Evidence = append(tech.Evidence, evidence)
+	for name, value := range evidence.Captures {
+		if tech.Extracted == nil {
+			tech.Extracted = make(map[string][]string)
+		}
+		tech.Extracted[name] = appendUnique(tech.Extracted[name], value)
+	}
+}
+
+// newDetection creates a detection of a signature without evidence
+func newDetection(sig models.Signature) models.DetectedTechnology {
+	return models.DetectedTechnology{
+		Name:         sig.Name,
+		Category:     sig.Category,
+		CategoryID:   sig.CategoryID,
+		CategoryPath: sig.CategoryPath,
+		Description:  sig.Description,
+		Website:      sig.Website,
+	}
+}
+
+// technologies returns the detections with their confidence computed
+func (d *detectionSet) technologies() []models.DetectedTechnology {
+	detectedTechnologies := make([]models.DetectedTechnology, 0, len(d.detected))
+	for _, tech := range d.detected {
+		tech.Confidence = confidence(tech.Evidence)
+		if len(tech.Evidence) == 0 && d.conditionOnly[tech.Name] {
+			tech.Confidence = negationWeight
+		}
+		detectedTechnologies = append(detectedTechnologies, tech)
+	}
 	return detectedTechnologies
 }
 
+// newEvidence describes a record matched by a pattern
+func newEvidence(record models.DNSResponse, pattern compiledPattern, match []string) models.Evidence {
+	return models.Evidence{
+		RecordType: record.RecordType,
+		Name:       record.Owner(),
+		Value:      record.Value,
+		Pattern:    pattern.source,
+		Weight:     patternWeight(pattern, record.RecordType),
+		Captures:   namedCaptures(pattern.re, match),
+	}
+}
+
+// namedCaptures returns the non-empty named groups of a regex match
+func namedCaptures(re *regexp.Regexp, match []string) map[string]string {
+	var captures map[string]string
+	if re == nil {
+		return nil
+	}
+	for i, name := range re.SubexpNames() {
+		if name == "" || match[i] == "" {
+			continue
+		}
+		if captures == nil {
+			captures = make(map[string]string)
+		}
+		captures[name] = match[i]
+	}
+	return captures
+}
+
+// fieldOf returns the record field a pattern matches
+func fieldOf(pattern compiledPattern) string {
+	if pattern.field == models.FieldName {
+		return models.FieldName
+	}
+	return models.FieldValue
+}
+
+// patternWeight returns the weight of a match, falling back to the default
+// for the record type
+func patternWeight(pattern compiledPattern, recordType string) int {
+	if pattern.weight > 0 {
+		return pattern.weight
+	}
+	if weight, ok := defaultPatternWeights[recordType]; ok {
+		return weight
+	}
+	return defaultPatternWeight
+}
+
+// confidence combines evidence weights into a 0-100 score. Each piece of
+// evidence is treated as an independent chance that the detection is right,
+// so corroborating records raise the score without ever exceeding 100.
+func confidence(evidence []models.Evidence) int {
+	doubt := 1.0
+	for _, item := range evidence {
+		doubt *= 1 - float64(item.Weight)/100
+	}
+	return int(math.Round((1 - doubt) * 100))
+}
+
+// FilterByConfidence drops detections scoring below minConfidence
+func FilterByConfidence(technologies []models.DetectedTechnology, minConfidence int) []models.DetectedTechnology {
+	if minConfidence <= 0 {
+		return technologies
+	}
+	filtered := make([]models.DetectedTechnology, 0, len(technologies))
+	for _, tech := range technologies {
+		if tech.Confidence >= minConfidence {
+			filtered = append(filtered, tech)
+		}
+	}
+	return filtered
+}
+
+// DetectTechnologies identifies technologies from DNS records using signatures.
+// It compiles the signatures on every call; callers that detect more than
+// once should build a Matcher with NewMatcher instead.
+func DetectTechnologies(records []models.DNSResponse, signatures models.SignatureFile) []models.DetectedTechnology {
+	matcher, err := NewMatcher(signatures)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return matcher.Detect(records)
+}
+
+// mergeIndexes merges two ascending index lists into one
+func mergeIndexes(a, b []int) []int {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+
+	merged := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			merged = append(merged, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	return merged
+}
+
 // containsString checks if a string exists in a slice
 func containsString(slice []string, item string) bool {
 	for _, s := range slice {
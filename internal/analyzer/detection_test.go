@@ -1,7 +1,11 @@
 package analyzer
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Elite-Security-Systems/radar/internal/models"
@@ -31,7 +35,7 @@ func TestDetectTechnologies(t *testing.T) {
 						Category:    "Hosting Provider",
 						Description: "Linode cloud hosting",
 						RecordTypes: []string{"NS"},
-						Patterns:    []string{"\\.linode\\.com\\."},
+						Patterns:    []models.Pattern{{Regex: "\\.linode\\.com\\."}},
 						Website:     "https://www.linode.com/",
 					},
 				},
@@ -42,8 +46,10 @@ func TestDetectTechnologies(t *testing.T) {
 					Category:    "Hosting Provider",
 					Description: "Linode cloud hosting",
 					Website:     "https://www.linode.com/",
-					Evidence:    "ns1.linode.com.",
-					RecordType:  "NS",
+					Confidence:  90,
+					Evidence: []models.Evidence{
+						{RecordType: "NS", Name: "example.com.", Value: "ns1.linode.com.", Pattern: "\\.linode\\.com\\.", Weight: 90},
+					},
 				},
 			},
 		},
@@ -64,7 +70,7 @@ func TestDetectTechnologies(t *testing.T) {
 						Category:    "Email Security",
 						Description: "Sender Policy Framework",
 						RecordTypes: []string{"TXT"},
-						Patterns:    []string{"v=spf1\\s.*"},
+						Patterns:    []models.Pattern{{Regex: "v=spf1\\s.*"}},
 						Website:     "https://dmarcian.com/spf-overview/",
 					},
 				},
@@ -75,8 +81,10 @@ func TestDetectTechnologies(t *testing.T) {
 					Category:    "Email Security",
 					Description: "Sender Policy Framework",
 					Website:     "https://dmarcian.com/spf-overview/",
-					Evidence:    "v=spf1 +a +mx ~all",
-					RecordType:  "TXT",
+					Confidence:  50,
+					Evidence: []models.Evidence{
+						{RecordType: "TXT", Name: "example.com.", Value: "v=spf1 +a +mx ~all", Pattern: "v=spf1\\s.*", Weight: 50},
+					},
 				},
 			},
 		},
@@ -97,7 +105,7 @@ func TestDetectTechnologies(t *testing.T) {
 						Category:    "Email Security",
 						Description: "Sender Policy Framework",
 						RecordTypes: []string{"TXT"},
-						Patterns:    []string{"v=spf1\\s.*"},
+						Patterns:    []models.Pattern{{Regex: "v=spf1\\s.*"}},
 						Website:     "https://dmarcian.com/spf-overview/",
 					},
 				},
@@ -105,7 +113,7 @@ func TestDetectTechnologies(t *testing.T) {
 			expected: make([]models.DetectedTechnology, 0),
 		},
 		{
-			name: "Multiple matches, deduplicate",
+			name: "Multiple matches, one detection with all evidence",
 			records: []models.DNSResponse{
 				{
 					Domain:     "example.com.",
@@ -127,7 +135,7 @@ func TestDetectTechnologies(t *testing.T) {
 						Category:    "Hosting Provider",
 						Description: "Linode cloud hosting",
 						RecordTypes: []string{"NS"},
-						Patterns:    []string{"\\.linode\\.com\\."},
+						Patterns:    []models.Pattern{{Regex: "\\.linode\\.com\\."}},
 						Website:     "https://www.linode.com/",
 					},
 				},
@@ -138,8 +146,11 @@ func TestDetectTechnologies(t *testing.T) {
 					Category:    "Hosting Provider",
 					Description: "Linode cloud hosting",
 					Website:     "https://www.linode.com/",
-					Evidence:    "ns1.linode.com.",
-					RecordType:  "NS",
+					Confidence:  99,
+					Evidence: []models.Evidence{
+						{RecordType: "NS", Name: "example.com.", Value: "ns1.linode.com.", Pattern: "\\.linode\\.com\\.", Weight: 90},
+						{RecordType: "NS", Name: "example.com.", Value: "ns2.linode.com.", Pattern: "\\.linode\\.com\\.", Weight: 90},
+					},
 				},
 			},
 		},
@@ -148,7 +159,7 @@ func TestDetectTechnologies(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			result := DetectTechnologies(tc.records, tc.signatures)
-			
+
 			if !reflect.DeepEqual(result, tc.expected) {
 				t.Errorf("Expected %+v, got %+v", tc.expected, result)
 			}
@@ -156,6 +167,128 @@ func TestDetectTechnologies(t *testing.T) {
 	}
 }
 
+func TestShippedSignatures(t *testing.T) {
+	data, err := os.ReadFile("../../data/signatures.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var file models.SignatureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	matcher, err := NewMatcher(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Loose patterns that used to match inside other values
+	testCases := []struct {
+		name     string
+		record   models.DNSResponse
+		expected string
+		rejected string
+	}{
+		{"Dropbox token is not Box", models.DNSResponse{RecordType: "TXT", Value: "dropbox-domain-verification=abc123"}, "Dropbox", "Box"},
+		{"Box token", models.DNSResponse{RecordType: "TXT", Value: "box-domain-verification=abc123"}, "Box", "Dropbox"},
+		{"Constant Contact token", models.DNSResponse{RecordType: "TXT", Value: "ca3-0f1e2d3c"}, "Constant Contact", ""},
+		{"ca3- inside another value", models.DNSResponse{RecordType: "TXT", Value: "v=spf1 include:ca3-0f1e.example.net -all"}, "", "Constant Contact"},
+		{"Cursor token", models.DNSResponse{RecordType: "TXT", Value: "cursor-domain-verification-x1=abc"}, "Cursor", ""},
+		{"Linode name under another domain", models.DNSResponse{RecordType: "NS", Value: "ns1.linode.com.example.net."}, "", "Linode DNS"},
+		{"Linode nameserver", models.DNSResponse{RecordType: "NS", Value: "ns1.linode.com."}, "Linode DNS", ""},
+		{"Fastmail MX", models.DNSResponse{RecordType: "MX", Value: "10 in.fastmail.com."}, "Fastmail", ""},
+		{"Fastmail name inside another host", models.DNSResponse{RecordType: "MX", Value: "10 login.fastmail.com."}, "", "Fastmail"},
+		{"Zoho MX", models.DNSResponse{RecordType: "MX", Value: "10 mx.zoho.com."}, "Zoho Mail", ""},
+		{"Zoho name inside another host", models.DNSResponse{RecordType: "MX", Value: "10 mx.zoho.com.example.net."}, "", "Zoho Mail"},
+		{"ProtonMail MX", models.DNSResponse{RecordType: "MX", Value: "10 mailsec.protonmail.ch."}, "ProtonMail", ""},
+		{"ProtonMail name inside another host", models.DNSResponse{RecordType: "MX", Value: "10 notprotonmail.ch."}, "", "ProtonMail"},
+		{"Amazon SES include", models.DNSResponse{RecordType: "TXT", Value: "v=spf1 include:amazonses.com ~all"}, "Amazon SES", ""},
+		{"Amazon SES include under another domain", models.DNSResponse{RecordType: "TXT", Value: "v=spf1 include:amazonses.com.evil.net ~all"}, "", "Amazon SES"},
+		{"SendGrid include at the end", models.DNSResponse{RecordType: "TXT", Value: "v=spf1 include:sendgrid.net"}, "SendGrid", ""},
+		{"SendGrid include under another domain", models.DNSResponse{RecordType: "TXT", Value: "v=spf1 include:sendgrid.net.example.com -all"}, "", "SendGrid"},
+		{"Let's Encrypt CAA", models.DNSResponse{RecordType: "CAA", Value: `0 issue "letsencrypt.org"`}, "Let's Encrypt", ""},
+		{"Let's Encrypt CAA with parameters", models.DNSResponse{RecordType: "CAA", Value: `0 issuewild "letsencrypt.org; validationmethods=dns-01"`}, "Let's Encrypt", ""},
+		{"Let's Encrypt in an iodef address", models.DNSResponse{RecordType: "CAA", Value: `0 iodef "mailto:caa@letsencrypt.org.example.com"`}, "", "Let's Encrypt"},
+		{"DigiCert name inside another CA", models.DNSResponse{RecordType: "CAA", Value: `0 issue "notdigicert.com"`}, "", "DigiCert"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.record.Domain = "example.com."
+			detected := make(map[string]bool)
+			for _, tech := range matcher.Detect([]models.DNSResponse{tc.record}) {
+				detected[tech.Name] = true
+			}
+			if tc.expected != "" && !detected[tc.expected] {
+				t.Errorf("Expected %s to be detected, got %v", tc.expected, detected)
+			}
+			if tc.rejected != "" && detected[tc.rejected] {
+				t.Errorf("Expected %s not to be detected", tc.rejected)
+			}
+		})
+	}
+
+	// Signatures that need several records
+	conditionCases := []struct {
+		name      string
+		records   []models.DNSResponse
+		signature string
+		detected  bool
+	}{
+		{
+			name: "Autodiscover host with on-premises mail",
+			records: []models.DNSResponse{
+				{Domain: "autodiscover.example.com.", RecordType: "A", Value: "192.0.2.25"},
+				{Domain: "example.com.", RecordType: "MX", Value: "10 mail.example.com."},
+			},
+			signature: "Microsoft Exchange Server",
+			detected:  true,
+		},
+		{
+			name: "Autodiscover host with Microsoft 365 mail",
+			records: []models.DNSResponse{
+				{Domain: "autodiscover.example.com.", RecordType: "CNAME", Value: "autodiscover.outlook.com."},
+				{Domain: "example.com.", RecordType: "MX", Value: "0 example-com.mail.protection.outlook.com."},
+			},
+			signature: "Microsoft Exchange Server",
+		},
+		{
+			name: "All Cloudflare route MX hosts",
+			records: []models.DNSResponse{
+				{Domain: "example.com.", RecordType: "MX", Value: "13 route1.mx.cloudflare.net."},
+				{Domain: "example.com.", RecordType: "MX", Value: "86 route2.mx.cloudflare.net."},
+				{Domain: "example.com.", RecordType: "MX", Value: "24 route3.mx.cloudflare.net."},
+			},
+			signature: "Cloudflare Email Routing",
+			detected:  true,
+		},
+		{
+			name: "A single Cloudflare route MX host",
+			records: []models.DNSResponse{
+				{Domain: "example.com.", RecordType: "MX", Value: "13 route1.mx.cloudflare.net."},
+			},
+			signature: "Cloudflare Email Routing",
+		},
+	}
+
+	for _, tc := range conditionCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var found *models.DetectedTechnology
+			detected := matcher.Detect(tc.records)
+			for i := range detected {
+				if detected[i].Name == tc.signature {
+					found = &detected[i]
+				}
+			}
+			if (found != nil) != tc.detected {
+				t.Fatalf("Expected %s detected to be %v, got %+v", tc.signature, tc.detected, detected)
+			}
+			if found != nil && (len(found.Evidence) == 0 || found.Confidence == 0) {
+				t.Errorf("Expected %s to have evidence and confidence, got %+v", tc.signature, found)
+			}
+		})
+	}
+}
+
 func TestContainsString(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -192,10 +325,501 @@ func TestContainsString(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			result := containsString(tc.slice, tc.item)
-			
+
 			if result != tc.expected {
 				t.Errorf("Expected %v, got %v", tc.expected, result)
 			}
 		})
 	}
 }
+
+func TestMatcher(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{Name: "Broken", RecordTypes: []string{"TXT"}, Patterns: []models.Pattern{{Regex: "(unclosed"}, {Regex: "broken-token"}}},
+			{Name: "Anything", RecordTypes: []string{"*"}, Patterns: []models.Pattern{{Regex: "^anything="}}},
+			{Name: "Mail", RecordTypes: []string{"MX", "TXT"}, Patterns: []models.Pattern{{Regex: "mail\\.example\\.net"}}},
+			{Name: "Web", RecordTypes: []string{"CNAME"}, Patterns: []models.Pattern{{Regex: "web\\.example\\.net"}}},
+		},
+	}
+
+	matcher, err := NewMatcher(signatures)
+	if err == nil || !strings.Contains(err.Error(), "signature Broken") {
+		t.Fatalf("Expected the invalid pattern to be reported, got %v", err)
+	}
+	if matcher.Len() != 4 {
+		t.Fatalf("Expected 4 signatures, got %d", matcher.Len())
+	}
+
+	records := []models.DNSResponse{
+		{Domain: "example.com.", RecordType: "A", Value: "192.0.2.1"},
+		{Domain: "example.com.", RecordType: "TXT", Value: "broken-token"},
+		{Domain: "example.com.", RecordType: "CAA", Value: "anything=1"},
+		{Domain: "example.com.", RecordType: "MX", Value: "10 mail.example.net."},
+		{Domain: "example.com.", RecordType: "TXT", Value: "v=spf1 include:mail.example.net -all"},
+		{Domain: "example.com.", RecordType: "MX", Value: "10 web.example.net."},
+	}
+
+	var names []string
+	for _, tech := range matcher.Detect(records) {
+		for _, evidence := range tech.Evidence {
+			names = append(names, tech.Name+"/"+evidence.RecordType)
+		}
+	}
+	expected := []string{"Broken/TXT", "Anything/CAA", "Mail/MX", "Mail/TXT"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestMergeIndexes(t *testing.T) {
+	testCases := []struct {
+		a, b, expected []int
+	}{
+		{nil, nil, nil},
+		{[]int{1, 3}, nil, []int{1, 3}},
+		{nil, []int{2}, []int{2}},
+		{[]int{1, 4, 6}, []int{2, 4, 7}, []int{1, 2, 4, 6, 7}},
+	}
+
+	for _, tc := range testCases {
+		if result := mergeIndexes(tc.a, tc.b); !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("mergeIndexes(%v, %v): expected %v, got %v", tc.a, tc.b, tc.expected, result)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{
+				Name:        "Mail Provider",
+				RecordTypes: []string{"MX", "TXT"},
+				Patterns: []models.Pattern{
+					{Regex: "mx\\.provider\\.example"},
+					{Regex: "^provider-verification=", Weight: 20},
+				},
+			},
+		},
+	}
+	matcher, err := NewMatcher(signatures)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		records    []models.DNSResponse
+		confidence int
+	}{
+		{
+			name:       "Verification token only",
+			records:    []models.DNSResponse{{RecordType: "TXT", Value: "provider-verification=abc"}},
+			confidence: 20,
+		},
+		{
+			name:       "MX with the default weight",
+			records:    []models.DNSResponse{{RecordType: "MX", Value: "10 mx.provider.example."}},
+			confidence: 90,
+		},
+		{
+			name: "Corroborating records",
+			records: []models.DNSResponse{
+				{RecordType: "TXT", Value: "provider-verification=abc"},
+				{RecordType: "MX", Value: "10 mx.provider.example."},
+			},
+			confidence: 92,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detected := matcher.Detect(tc.records)
+			if len(detected) != 1 {
+				t.Fatalf("Expected one detection, got %+v", detected)
+			}
+			if detected[0].Confidence != tc.confidence {
+				t.Errorf("Expected confidence %d, got %d", tc.confidence, detected[0].Confidence)
+			}
+			if filtered := FilterByConfidence(detected, tc.confidence+1); len(filtered) != 0 {
+				t.Errorf("Expected the detection to be filtered out above its confidence")
+			}
+			if filtered := FilterByConfidence(detected, tc.confidence); len(filtered) != 1 {
+				t.Errorf("Expected the detection to pass at its confidence")
+			}
+		})
+	}
+}
+
+func TestCaptureExtraction(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{
+				Name:        "Microsoft 365",
+				RecordTypes: []string{"MX", "TXT"},
+				Patterns: []models.Pattern{
+					{Regex: "outlook\\.com$"},
+					{Regex: "MS=(?P<tenantToken>ms\\d+)"},
+				},
+			},
+			{
+				Name:        "Zendesk",
+				RecordTypes: []string{"CNAME"},
+				Patterns:    []models.Pattern{{Regex: "^(?P<subdomain>[a-z0-9-]+)\\.zendesk\\.com$"}},
+			},
+		},
+	}
+	matcher, err := NewMatcher(signatures)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	records := []models.DNSResponse{
+		{Domain: "example.com.", RecordType: "TXT", Value: "MS=ms12345678"},
+		{Domain: "example.com.", RecordType: "MX", Value: "0 example-com.mail.protection.outlook.com."},
+		{Domain: "example.com.", RecordType: "TXT", Value: "MS=ms87654321"},
+		{Domain: "example.com.", RecordType: "TXT", Value: "MS=ms12345678 "},
+		{Domain: "support.example.com.", RecordType: "CNAME", Value: "examplecorp.zendesk.com."},
+	}
+
+	detected := matcher.Detect(records)
+	if len(detected) != 2 {
+		t.Fatalf("Expected two detections, got %+v", detected)
+	}
+
+	expected := map[string][]string{"tenantToken": {"ms12345678", "ms87654321"}}
+	if !reflect.DeepEqual(detected[0].Extracted, expected) {
+		t.Errorf("Expected %v, got %v", expected, detected[0].Extracted)
+	}
+	if detected[0].Evidence[1].Captures != nil {
+		t.Errorf("Expected no captures for the MX evidence, got %v", detected[0].Evidence[1].Captures)
+	}
+
+	expected = map[string][]string{"subdomain": {"examplecorp"}}
+	if !reflect.DeepEqual(detected[1].Extracted, expected) {
+		t.Errorf("Expected %v, got %v", expected, detected[1].Extracted)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{
+				Name:        "Proofpoint Essentials",
+				RecordTypes: []string{"MX", "TXT"},
+				Condition: &models.Condition{All: []models.Condition{
+					{RecordTypes: []string{"MX"}, Match: &models.Pattern{Regex: "\\.ppe-hosted\\.com$"}},
+					{RecordTypes: []string{"TXT"}, Match: &models.Pattern{Regex: "^v=spf1 .*ppe-hosted\\.com"}},
+				}},
+			},
+			{
+				Name:        "Self-hosted Mail",
+				RecordTypes: []string{"MX"},
+				Patterns:    []models.Pattern{{Regex: "^[0-9]+ mail\\.example\\.com\\.$"}},
+				Condition: &models.Condition{Not: &models.Condition{
+					Match: &models.Pattern{Regex: "outlook\\.com$"},
+				}},
+			},
+			{
+				Name:        "Redundant MX",
+				RecordTypes: []string{"MX"},
+				Condition:   &models.Condition{Match: &models.Pattern{Regex: "."}, MinCount: 2},
+			},
+			{
+				Name: "Two of three",
+				Condition: &models.Condition{Min: 2, Any: []models.Condition{
+					{RecordTypes: []string{"TXT"}, Match: &models.Pattern{Regex: "^a="}},
+					{RecordTypes: []string{"TXT"}, Match: &models.Pattern{Regex: "^b="}},
+					{RecordTypes: []string{"TXT"}, Match: &models.Pattern{Regex: "^c="}},
+				}},
+			},
+			{
+				Name:      "Broken condition",
+				Condition: &models.Condition{Not: &models.Condition{Match: &models.Pattern{Regex: "("}}},
+			},
+		},
+	}
+
+	matcher, err := NewMatcher(signatures)
+	if err == nil || !strings.Contains(err.Error(), "Broken condition") {
+		t.Fatalf("Expected the broken condition to be reported, got %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		records  []models.DNSResponse
+		expected []string
+	}{
+		{
+			name: "MX without the SPF include",
+			records: []models.DNSResponse{
+				{RecordType: "MX", Value: "10 mx1-us1.ppe-hosted.com."},
+			},
+			expected: []string{},
+		},
+		{
+			name: "MX and SPF include",
+			records: []models.DNSResponse{
+				{RecordType: "MX", Value: "10 mx1-us1.ppe-hosted.com."},
+				{RecordType: "MX", Value: "20 mx2-us1.ppe-hosted.com."},
+				{RecordType: "TXT", Value: "v=spf1 a:dispatch-us.ppe-hosted.com -all"},
+			},
+			expected: []string{"Proofpoint Essentials/3", "Redundant MX/2"},
+		},
+		{
+			name: "Self-hosted mail excluded by a Microsoft 365 MX",
+			records: []models.DNSResponse{
+				{RecordType: "MX", Value: "10 mail.example.com."},
+				{RecordType: "MX", Value: "0 example-com.mail.protection.outlook.com."},
+			},
+			expected: []string{"Redundant MX/2"},
+		},
+		{
+			name: "Self-hosted mail and two of three tokens",
+			records: []models.DNSResponse{
+				{RecordType: "MX", Value: "10 mail.example.com."},
+				{RecordType: "TXT", Value: "a=1"},
+				{RecordType: "TXT", Value: "c=1"},
+			},
+			expected: []string{"Self-hosted Mail/1", "Two of three/2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detected := []string{}
+			for _, tech := range matcher.Detect(tc.records) {
+				detected = append(detected, fmt.Sprintf("%s/%d", tech.Name, len(tech.Evidence)))
+			}
+			if !reflect.DeepEqual(detected, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, detected)
+			}
+		})
+	}
+}
+
+func TestNegationOnlyConfidence(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{
+				Name:        "No Sender Policy",
+				RecordTypes: []string{"TXT"},
+				Condition: &models.Condition{Not: &models.Condition{
+					Match: &models.Pattern{Prefix: "v=spf1 "},
+				}},
+			},
+		},
+	}
+	matcher, err := NewMatcher(signatures)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	detected := matcher.Detect([]models.DNSResponse{{RecordType: "MX", Value: "10 mail.example.com."}})
+	if len(detected) != 1 || len(detected[0].Evidence) != 0 {
+		t.Fatalf("Expected one detection without evidence, got %+v", detected)
+	}
+	if detected[0].Confidence != negationWeight {
+		t.Errorf("Expected confidence %d, got %d", negationWeight, detected[0].Confidence)
+	}
+	if filtered := FilterByConfidence(detected, 30); len(filtered) != 1 {
+		t.Errorf("Expected the detection to pass a minimum confidence of 30")
+	}
+
+	if detected := matcher.Detect([]models.DNSResponse{{RecordType: "TXT", Value: "v=spf1 -all"}}); len(detected) != 0 {
+		t.Errorf("Expected no detection with an SPF record, got %+v", detected)
+	}
+}
+
+func TestRelations(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{Name: "Suite", RecordTypes: []string{"TXT"}, Patterns: []models.Pattern{{Regex: "^suite=", Weight: 40}}, Implies: []string{"Mail", "Directory"}},
+			{Name: "Mail", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Regex: "mail\\.suite\\.example\\.$"}}, Implies: []string{"Relay"}},
+			{Name: "Directory", Implies: []string{"Suite"}},
+			{Name: "Relay", Excludes: []string{"Other Relay"}},
+			{Name: "Other Relay", RecordTypes: []string{"TXT"}, Patterns: []models.Pattern{{Regex: "^other-relay="}}},
+			{Name: "Stale", RecordTypes: []string{"TXT"}, Patterns: []models.Pattern{{Regex: "^stale="}}, Excludes: []string{"Mail"}},
+			{Name: "Dangling", Implies: []string{"Missing"}, Excludes: []string{"Gone"}},
+		},
+	}
+
+	matcher, err := NewMatcher(signatures)
+	if err == nil || !strings.Contains(err.Error(), `implies unknown technology "Missing"`) ||
+		!strings.Contains(err.Error(), `excludes unknown technology "Gone"`) {
+		t.Fatalf("Expected the unknown relations to be reported, got %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		records  []models.DNSResponse
+		expected []string
+	}{
+		{
+			name:     "implied technologies are inferred transitively",
+			records:  []models.DNSResponse{{RecordType: "TXT", Value: "suite=1"}},
+			expected: []string{"Suite/40", "Mail/40/inferred/Suite", "Directory/40/inferred/Suite", "Relay/40/inferred/Mail"},
+		},
+		{
+			name: "a detected technology keeps its own confidence",
+			records: []models.DNSResponse{
+				{RecordType: "TXT", Value: "suite=1"},
+				{RecordType: "MX", Value: "10 mail.suite.example."},
+			},
+			expected: []string{"Suite/40", "Mail/90/Suite", "Directory/40/inferred/Suite", "Relay/90/inferred/Mail"},
+		},
+		{
+			name: "the stronger exclusive detection wins",
+			records: []models.DNSResponse{
+				{RecordType: "MX", Value: "10 mail.suite.example."},
+				{RecordType: "TXT", Value: "stale=1"},
+			},
+			expected: []string{"Mail/90", "Relay/90/inferred/Mail"},
+		},
+		{
+			name: "an excluded technology is not inferred",
+			records: []models.DNSResponse{
+				{RecordType: "TXT", Value: "other-relay=1"},
+				{RecordType: "MX", Value: "10 mail.suite.example."},
+			},
+			expected: []string{"Other Relay/50", "Mail/90"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detected := []string{}
+			for _, tech := range matcher.Detect(tc.records) {
+				description := fmt.Sprintf("%s/%d", tech.Name, tech.Confidence)
+				if tech.Inferred {
+					description += "/inferred"
+				}
+				if len(tech.ImpliedBy) > 0 {
+					description += "/" + strings.Join(tech.ImpliedBy, ",")
+				}
+				detected = append(detected, description)
+			}
+			if !reflect.DeepEqual(detected, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, detected)
+			}
+		})
+	}
+}
+
+func TestNameMatching(t *testing.T) {
+	signatures := models.SignatureFile{
+		Signatures: []models.Signature{
+			{
+				Name:        "GitHub",
+				RecordTypes: []string{"TXT"},
+				Patterns:    []models.Pattern{{Regex: "^_github-challenge-(?P<organization>[a-z0-9-]+)\\.", Field: models.FieldName}},
+			},
+			{
+				Name:        "Edge CDN",
+				RecordTypes: []string{"A"},
+				Patterns:    []models.Pattern{{Regex: "\\.edge\\.example\\.net$", Field: models.FieldName}},
+			},
+			{
+				Name:        "DKIM Selector",
+				RecordTypes: []string{"TXT"},
+				Condition: &models.Condition{All: []models.Condition{
+					{Match: &models.Pattern{Regex: "^selector1\\._domainkey\\.", Field: models.FieldName}},
+					{Match: &models.Pattern{Regex: "^v=DKIM1"}},
+				}},
+			},
+		},
+	}
+
+	matcher, err := NewMatcher(signatures)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	records := []models.DNSResponse{
+		{Domain: "example.com.", Name: "_github-challenge-Acme-Org.example.com.", RecordType: "TXT", Value: "0123456789"},
+		{Domain: "example.com.", Name: "example.com.edge.example.net.", RecordType: "A", Value: "192.0.2.1"},
+		// Records saved without an owner name fall back to the queried name
+		{Domain: "selector1._domainkey.example.com.", RecordType: "TXT", Value: "v=DKIM1; k=rsa; p=MIGf"},
+		{Domain: "example.com.", RecordType: "TXT", Value: "_github-challenge-other.example.com"},
+	}
+
+	var detected []string
+	for _, tech := range matcher.Detect(records) {
+		for _, evidence := range tech.Evidence {
+			detected = append(detected, tech.Name+"/"+evidence.Name)
+		}
+		if tech.Name == "GitHub" && !reflect.DeepEqual(tech.Extracted, map[string][]string{"organization": {"acme-org"}}) {
+			t.Errorf("Expected the organization from the owner name, got %v", tech.Extracted)
+		}
+	}
+	expected := []string{
+		"GitHub/_github-challenge-Acme-Org.example.com.",
+		"Edge CDN/example.com.edge.example.net.",
+		"DKIM Selector/selector1._domainkey.example.com.",
+	}
+	if !reflect.DeepEqual(detected, expected) {
+		t.Errorf("Expected %v, got %v", expected, detected)
+	}
+}
+
+func TestTypedPatterns(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern models.Pattern
+		record  models.DNSResponse
+		matches bool
+	}{
+		{"suffix on a label boundary", models.Pattern{Suffix: ".linode.com"}, models.DNSResponse{RecordType: "NS", Value: "NS1.Linode.com."}, true},
+		{"suffix without a leading dot", models.Pattern{Suffix: "linode.com"}, models.DNSResponse{RecordType: "NS", Value: "ns1.linode.com."}, true},
+		{"suffix after an MX priority", models.Pattern{Suffix: "linode.com"}, models.DNSResponse{RecordType: "MX", Value: "10 linode.com."}, true},
+		{"suffix inside a label", models.Pattern{Suffix: ".linode.com"}, models.DNSResponse{RecordType: "NS", Value: "ns1.notlinode.com."}, false},
+		{"exact is case-sensitive", models.Pattern{Exact: "v=spf1 -all"}, models.DNSResponse{RecordType: "TXT", Value: "V=SPF1 -ALL"}, false},
+		{"exact ignoring case", models.Pattern{Exact: "v=spf1 -all", IgnoreCase: true}, models.DNSResponse{RecordType: "TXT", Value: "V=SPF1 -ALL"}, true},
+		{"exact without the trailing dot", models.Pattern{Exact: "example.netlify.app"}, models.DNSResponse{RecordType: "CNAME", Value: "example.netlify.app."}, true},
+		{"prefix", models.Pattern{Prefix: "google-site-verification="}, models.DNSResponse{RecordType: "TXT", Value: "google-site-verification=abc"}, true},
+		{"prefix elsewhere in the value", models.Pattern{Prefix: "google-site-verification="}, models.DNSResponse{RecordType: "TXT", Value: "x google-site-verification=abc"}, false},
+		{"contains", models.Pattern{Contains: "include:_spf.google.com"}, models.DNSResponse{RecordType: "TXT", Value: "v=spf1 include:_spf.google.com ~all"}, true},
+		{"contains takes dots literally", models.Pattern{Contains: "a.b"}, models.DNSResponse{RecordType: "TXT", Value: "axb"}, false},
+		{"name prefix ignores case", models.Pattern{Prefix: "_github-challenge-", Field: models.FieldName}, models.DNSResponse{RecordType: "TXT", Name: "_GitHub-Challenge-acme.example.com.", Value: "x"}, true},
+		{"IPv4 in network", models.Pattern{CIDR: "141.193.213.0/24"}, models.DNSResponse{RecordType: "A", Value: "141.193.213.10"}, true},
+		{"IPv4 outside network", models.Pattern{CIDR: "141.193.213.0/24"}, models.DNSResponse{RecordType: "A", Value: "141.193.214.10"}, false},
+		{"IPv6 in network", models.Pattern{CIDR: "2606:4700::/32"}, models.DNSResponse{RecordType: "AAAA", Value: "2606:4700:3030::6815:1234"}, true},
+		{"network against a host name", models.Pattern{CIDR: "192.0.2.0/24"}, models.DNSResponse{RecordType: "CNAME", Value: "example.net."}, false},
+		{"TTL range alone", models.Pattern{TTL: &models.Range{Max: 60}}, models.DNSResponse{RecordType: "A", TTL: 20, Value: "192.0.2.1"}, true},
+		{"TTL range with a suffix", models.Pattern{Suffix: "elb.amazonaws.com", TTL: &models.Range{Min: 30, Max: 60}}, models.DNSResponse{RecordType: "CNAME", TTL: 300, Value: "x.elb.amazonaws.com."}, false},
+		{"open-ended TTL range", models.Pattern{TTL: &models.Range{Min: 86400}}, models.DNSResponse{RecordType: "NS", TTL: 172800, Value: "ns.example.net."}, true},
+		{"number in range", models.Pattern{Number: &models.Range{Min: 1, Max: 10}}, models.DNSResponse{RecordType: "TXT", Value: "7"}, true},
+		{"number outside range", models.Pattern{Number: &models.Range{Min: 1, Max: 10}}, models.DNSResponse{RecordType: "TXT", Value: "11"}, false},
+		{"number against text", models.Pattern{Number: &models.Range{Max: 10}}, models.DNSResponse{RecordType: "TXT", Value: "seven"}, false},
+		{"number in a token", models.Pattern{Number: &models.Range{Max: 604799}, Token: 6}, models.DNSResponse{RecordType: "SOA", Value: "ns1.example.com. hostmaster.example.com. 1 7200 900 86400 300"}, true},
+		{"suffix in a token", models.Pattern{Suffix: "example.com", Token: 2}, models.DNSResponse{RecordType: "SOA", Value: "ns1.example.net. hostmaster.example.com. 1 7200 900 86400 300"}, true},
+		{"token past the end", models.Pattern{Exact: "x", Token: 3}, models.DNSResponse{RecordType: "TXT", Value: "x y"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+				{Name: "Typed", RecordTypes: []string{tc.record.RecordType}, Patterns: []models.Pattern{tc.pattern}},
+			}})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			detected := matcher.Detect([]models.DNSResponse{tc.record})
+			if matches := len(detected) == 1; matches != tc.matches {
+				t.Fatalf("Expected match %v, got %v", tc.matches, detected)
+			}
+			if tc.matches && detected[0].Evidence[0].Pattern != tc.pattern.String() {
+				t.Errorf("Expected evidence pattern %q, got %q", tc.pattern.String(), detected[0].Evidence[0].Pattern)
+			}
+		})
+	}
+
+	_, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Bad network", RecordTypes: []string{"A"}, Patterns: []models.Pattern{{CIDR: "192.0.2.0/33"}}},
+		{Name: "Two kinds", RecordTypes: []string{"A"}, Patterns: []models.Pattern{{Exact: "a", Suffix: "b"}}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "Bad network") || !strings.Contains(err.Error(), "Two kinds") {
+		t.Errorf("Expected both invalid patterns to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// DMARC evaluation statuses
+const (
+	DMARCStatusNone      = "none"
+	DMARCStatusValid     = "valid"
+	DMARCStatusInvalid   = "invalid"
+	DMARCStatusTempError = "temperror"
+)
+
+// dmarcKnownTags lists the tags defined by RFC 7489 plus the DMARCbis
+// additions, anything else is reported as invalid
+var dmarcKnownTags = map[string]bool{
+	"v": true, "p": true, "sp": true, "pct": true, "adkim": true, "aspf": true,
+	"rua": true, "ruf": true, "fo": true, "rf": true, "ri": true,
+	"np": true, "psd": true, "t": true,
+}
+
+// AnalyzeDMARC looks up and parses the DMARC policy published at _dmarc.<domain>
+// and checks that external report destinations have authorized the domain
+func AnalyzeDMARC(ctx context.Context, resolver Resolver, domain string) *models.DMARCResult {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	result := &models.DMARCResult{Status: DMARCStatusNone}
+
+	records, err := resolver.Lookup(ctx, "_dmarc."+domain, "TXT")
+	if err != nil {
+		result.Status = DMARCStatusTempError
+		result.Errors = append(result.Errors, fmt.Sprintf("error looking up DMARC record for %s: %v", domain, err))
+		return result
+	}
+
+	var dmarcRecords []string
+	for _, record := range records {
+		if record.RecordType == "TXT" && isDMARCRecord(record.Value) {
+			dmarcRecords = append(dmarcRecords, record.Value)
+		}
+	}
+	if len(dmarcRecords) == 0 {
+		return result
+	}
+
+	result.Record = dmarcRecords[0]
+	if len(dmarcRecords) > 1 {
+		result.Status = DMARCStatusInvalid
+		result.Errors = append(result.Errors, "multiple DMARC records published, receivers will ignore the policy")
+		return result
+	}
+
+	parseDMARCRecord(result, domain)
+
+	for i := range result.AggregateReports {
+		checkReportAuthorization(ctx, resolver, domain, &result.AggregateReports[i], result)
+	}
+	for i := range result.ForensicReports {
+		checkReportAuthorization(ctx, resolver, domain, &result.ForensicReports[i], result)
+	}
+
+	return result
+}
+
+// parseDMARCRecord fills in the result from the tag list of a DMARC record
+func parseDMARCRecord(result *models.DMARCResult, domain string) {
+	result.Status = DMARCStatusValid
+	result.Percentage = 100
+	result.DKIMAlignment = "r"
+	result.SPFAlignment = "r"
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(result.Record, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, value, found := strings.Cut(part, "=")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		value = strings.TrimSpace(value)
+		if !found {
+			result.InvalidTags = append(result.InvalidTags, part)
+			continue
+		}
+		if seen[tag] {
+			invalidDMARC(result, fmt.Sprintf("duplicate %s tag", tag))
+			continue
+		}
+		seen[tag] = true
+
+		if !dmarcKnownTags[tag] {
+			result.InvalidTags = append(result.InvalidTags, part)
+			continue
+		}
+
+		switch tag {
+		case "p", "sp", "np":
+			policy := strings.ToLower(value)
+			if policy != "none" && policy != "quarantine" && policy != "reject" {
+				result.InvalidTags = append(result.InvalidTags, part)
+				if tag == "p" {
+					invalidDMARC(result, fmt.Sprintf("invalid policy %q", value))
+				}
+				continue
+			}
+			if tag == "p" {
+				result.Policy = policy
+			} else if tag == "sp" {
+				result.SubdomainPolicy = policy
+			}
+
+		case "pct":
+			pct, err := strconv.Atoi(value)
+			if err != nil || pct < 0 || pct > 100 {
+				result.InvalidTags = append(result.InvalidTags, part)
+				continue
+			}
+			result.Percentage = pct
+
+		case "adkim", "aspf":
+			mode := strings.ToLower(value)
+			if mode != "r" && mode != "s" {
+				result.InvalidTags = append(result.InvalidTags, part)
+				continue
+			}
+			if tag == "adkim" {
+				result.DKIMAlignment = mode
+			} else {
+				result.SPFAlignment = mode
+			}
+
+		case "fo":
+			for _, option := range strings.Split(value, ":") {
+				option = strings.TrimSpace(option)
+				if option != "0" && option != "1" && option != "d" && option != "s" {
+					result.InvalidTags = append(result.InvalidTags, part)
+					break
+				}
+				result.FailureOptions = append(result.FailureOptions, option)
+			}
+
+		case "ri":
+			interval, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				result.InvalidTags = append(result.InvalidTags, part)
+				continue
+			}
+			result.ReportInterval = uint32(interval)
+
+		case "rua", "ruf":
+			uris, ok := parseDMARCReportURIs(value, domain)
+			if !ok {
+				result.InvalidTags = append(result.InvalidTags, part)
+			}
+			if tag == "rua" {
+				result.AggregateReports = uris
+			} else {
+				result.ForensicReports = uris
+			}
+		}
+	}
+
+	if result.Policy == "" {
+		invalidDMARC(result, "missing required p tag")
+	}
+	if result.SubdomainPolicy == "" {
+		result.SubdomainPolicy = result.Policy
+	}
+
+	result.Enforced = result.Status == DMARCStatusValid &&
+		(result.Policy == "quarantine" || result.Policy == "reject") && result.Percentage == 100
+
+	if result.Policy == "none" {
+		result.Warnings = append(result.Warnings, "p=none only monitors mail and does not protect against spoofing")
+	}
+	if result.Percentage < 100 && result.Policy != "none" {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("policy is only applied to %d%% of failing mail", result.Percentage))
+	}
+	if len(result.AggregateReports) == 0 {
+		result.Warnings = append(result.Warnings, "no rua destination, aggregate reports will not be received")
+	}
+}
+
+// parseDMARCReportURIs splits a comma-separated rua/ruf value. It returns false
+// if any URI is malformed.
+func parseDMARCReportURIs(value, domain string) ([]models.DMARCReportURI, bool) {
+	var uris []models.DMARCReportURI
+	valid := true
+
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		report := models.DMARCReportURI{URI: raw}
+		// A trailing !size limits the report size
+		if i := strings.LastIndex(raw, "!"); i > 0 {
+			report.URI, report.MaxSize = raw[:i], raw[i+1:]
+		}
+
+		parsed, err := url.Parse(report.URI)
+		if err != nil || parsed.Scheme == "" {
+			valid = false
+			uris = append(uris, report)
+			continue
+		}
+
+		if strings.EqualFold(parsed.Scheme, "mailto") {
+			if _, host, ok := strings.Cut(parsed.Opaque, "@"); ok {
+				report.Domain = strings.TrimSuffix(strings.ToLower(host), ".")
+			}
+		} else {
+			report.Domain = strings.ToLower(parsed.Hostname())
+		}
+
+		report.External = report.Domain != "" && !sameOrganization(report.Domain, domain)
+		report.Authorized = !report.External
+		uris = append(uris, report)
+	}
+
+	return uris, valid
+}
+
+// checkReportAuthorization verifies an external destination publishes the
+// RFC 7489 <domain>._report._dmarc.<destination> authorization record
+func checkReportAuthorization(ctx context.Context, resolver Resolver, domain string, report *models.DMARCReportURI, result *models.DMARCResult) {
+	if !report.External {
+		return
+	}
+
+	name := domain + "._report._dmarc." + report.Domain
+	records, err := resolver.Lookup(ctx, name, "TXT")
+	if err != nil {
+		result.Errors = appendUnique(result.Errors, fmt.Sprintf("error looking up %s: %v", name, err))
+		return
+	}
+
+	for _, record := range records {
+		if record.RecordType == "TXT" && isDMARCRecord(record.Value) {
+			report.Authorized = true
+			return
+		}
+	}
+
+	result.Warnings = appendUnique(result.Warnings,
+		fmt.Sprintf("external report destination %s has not authorized reports for %s", report.Domain, domain))
+}
+
+// invalidDMARC marks the record invalid and records why
+func invalidDMARC(result *models.DMARCResult, message string) {
+	result.Status = DMARCStatusInvalid
+	result.Errors = appendUnique(result.Errors, message)
+}
+
+// isDMARCRecord checks whether a TXT value is a DMARC version 1 record
+func isDMARCRecord(value string) bool {
+	return hasVersionTag(value, "DMARC1")
+}
+
+// hasVersionTag checks whether a tag-list TXT value starts with v=<version>
+func hasVersionTag(value, version string) bool {
+	tag, _, _ := strings.Cut(strings.TrimSpace(value), ";")
+	return strings.EqualFold(strings.ReplaceAll(tag, " ", ""), "v="+version)
+}
+
+// sameOrganization reports whether two domains share an Organizational Domain
+// (RFC 7489 section 3.2), so a.example.com and b.example.com match. Names
+// without one, such as public suffixes, only match themselves.
+func sameOrganization(a, b string) bool {
+	if a == b {
+		return true
+	}
+	orgA, err := publicsuffix.EffectiveTLDPlusOne(a)
+	if err != nil {
+		return false
+	}
+	orgB, err := publicsuffix.EffectiveTLDPlusOne(b)
+	return err == nil && orgA == orgB
+}
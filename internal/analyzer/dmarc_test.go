@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeDMARC(t *testing.T) {
+	testCases := []struct {
+		name            string
+		records         fakeResolver
+		status          string
+		policy          string
+		subdomainPolicy string
+		percentage      int
+		enforced        bool
+		invalidTags     []string
+		authorized      []bool
+	}{
+		{
+			name:    "No DMARC record",
+			records: fakeResolver{},
+			status:  DMARCStatusNone,
+		},
+		{
+			name: "Enforced policy with internal reporting",
+			records: fakeResolver{
+				"_dmarc.example.com TXT": {"v=DMARC1; p=reject; rua=mailto:dmarc@example.com; adkim=s"},
+			},
+			status:          DMARCStatusValid,
+			policy:          "reject",
+			subdomainPolicy: "reject",
+			percentage:      100,
+			enforced:        true,
+			authorized:      []bool{true},
+		},
+		{
+			name: "Partial quarantine is not enforced",
+			records: fakeResolver{
+				"_dmarc.example.com TXT": {"v=DMARC1; p=quarantine; sp=none; pct=25"},
+			},
+			status:          DMARCStatusValid,
+			policy:          "quarantine",
+			subdomainPolicy: "none",
+			percentage:      25,
+		},
+		{
+			name: "External destinations need authorization",
+			records: fakeResolver{
+				"_dmarc.example.com TXT":                       {"v=DMARC1; p=none; rua=mailto:a@rua.agari.com,mailto:b@ag.dmarcian.com!10m"},
+				"example.com._report._dmarc.rua.agari.com TXT": {"v=DMARC1"},
+			},
+			status:          DMARCStatusValid,
+			policy:          "none",
+			subdomainPolicy: "none",
+			percentage:      100,
+			authorized:      []bool{true, false},
+		},
+		{
+			name: "Destinations in the same organization need no authorization",
+			records: fakeResolver{
+				"_dmarc.example.com TXT": {"v=DMARC1; p=none; rua=mailto:dmarc@reports.example.com,mailto:dmarc@example.co.uk"},
+			},
+			status:          DMARCStatusValid,
+			policy:          "none",
+			subdomainPolicy: "none",
+			percentage:      100,
+			authorized:      []bool{true, false},
+		},
+		{
+			name: "Invalid tags are reported",
+			records: fakeResolver{
+				"_dmarc.example.com TXT": {"v=DMARC1; p=reject; pct=150; foo=bar; adkim=x"},
+			},
+			status:          DMARCStatusValid,
+			policy:          "reject",
+			subdomainPolicy: "reject",
+			percentage:      100,
+			enforced:        true,
+			invalidTags:     []string{"pct=150", "foo=bar", "adkim=x"},
+		},
+		{
+			name: "Missing policy tag",
+			records: fakeResolver{
+				"_dmarc.example.com TXT": {"v=DMARC1; rua=mailto:dmarc@example.com"},
+			},
+			status:     DMARCStatusInvalid,
+			percentage: 100,
+			authorized: []bool{true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := AnalyzeDMARC(context.Background(), tc.records, "example.com.")
+
+			if result.Status != tc.status {
+				t.Errorf("Expected status %s, got %s (errors: %v)", tc.status, result.Status, result.Errors)
+			}
+			if result.Policy != tc.policy || result.SubdomainPolicy != tc.subdomainPolicy {
+				t.Errorf("Expected policy %q/%q, got %q/%q", tc.policy, tc.subdomainPolicy, result.Policy, result.SubdomainPolicy)
+			}
+			if result.Percentage != tc.percentage {
+				t.Errorf("Expected pct %d, got %d", tc.percentage, result.Percentage)
+			}
+			if result.Enforced != tc.enforced {
+				t.Errorf("Expected enforced %v, got %v", tc.enforced, result.Enforced)
+			}
+			if !reflect.DeepEqual(result.InvalidTags, tc.invalidTags) {
+				t.Errorf("Expected invalid tags %v, got %v", tc.invalidTags, result.InvalidTags)
+			}
+
+			var authorized []bool
+			for _, report := range result.AggregateReports {
+				authorized = append(authorized, report.Authorized)
+			}
+			if !reflect.DeepEqual(authorized, tc.authorized) {
+				t.Errorf("Expected authorization %v, got %v", tc.authorized, authorized)
+			}
+		})
+	}
+}
+
+func TestSameOrganization(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		same bool
+	}{
+		{"example.com", "example.com", true},
+		{"reports.example.com", "example.com", true},
+		{"a.example.com", "b.example.com", true},
+		{"mail.example.co.uk", "example.co.uk", true},
+		{"example.co.uk", "example.com", false},
+		{"a.github.io", "b.github.io", false},
+		{"co.uk", "example.co.uk", false},
+	}
+
+	for _, tc := range testCases {
+		if got := sameOrganization(tc.a, tc.b); got != tc.same {
+			t.Errorf("sameOrganization(%q, %q) = %v, expected %v", tc.a, tc.b, got, tc.same)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// dnssecFindings reports a zone that isn't signed. DNSKEY records only exist
+// at a zone's apex, so the lookup climbs from the name towards its
+// registrable domain until it finds them. Failed lookups report nothing.
+func dnssecFindings(ctx context.Context, resolver Resolver, name string) []models.Finding {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	apex, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		apex = name
+	}
+
+	for current := name; ; current = parentDomain(current) {
+		responses, err := resolver.Lookup(ctx, current, "DNSKEY")
+		if err != nil {
+			return nil
+		}
+		for _, response := range responses {
+			// Skip keys of a zone a CNAME points into
+			if response.RecordType == "DNSKEY" && strings.EqualFold(strings.TrimSuffix(response.Owner(), "."), current) {
+				return nil
+			}
+		}
+		if current == apex || !strings.Contains(current, ".") {
+			break
+		}
+	}
+
+	return []models.Finding{{
+		ID:          "dnssec-missing",
+		Severity:    models.SeverityLow,
+		Description: "the zone is not signed with DNSSEC, so its answers can be spoofed",
+		Evidence:    []string{fmt.Sprintf("no DNSKEY records at %s", apex)},
+		Remediation: "Sign the zone and publish its DS record at the registrar",
+	}}
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// failingResolver fails every lookup
+type failingResolver struct{}
+
+func (failingResolver) Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error) {
+	return nil, errors.New("timeout")
+}
+
+func TestDNSSECFindings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		scanned  string
+		resolver Resolver
+		missing  bool
+	}{
+		{"signed apex", "example.com", fakeResolver{"example.com DNSKEY": {"257 3 13 abc"}}, false},
+		{"subdomain of a signed zone", "www.shop.example.com", fakeResolver{"example.com DNSKEY": {"257 3 13 abc"}}, false},
+		{"unsigned zone", "www.example.co.uk", fakeResolver{"co.uk DNSKEY": {"257 3 8 def"}}, true},
+		{"failed lookup", "example.com", failingResolver{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := dnssecFindings(context.Background(), tc.resolver, tc.scanned)
+			if (len(findings) == 1) != tc.missing {
+				t.Errorf("Expected missing %v, got %v", tc.missing, findings)
+			}
+		})
+	}
+}
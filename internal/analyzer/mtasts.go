@@ -0,0 +1,323 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// MTA-STS and TLS-RPT evaluation statuses
+const (
+	MTASTSStatusNone      = "none"
+	MTASTSStatusValid     = "valid"
+	MTASTSStatusInvalid   = "invalid"
+	MTASTSStatusTempError = "temperror"
+)
+
+const (
+	// mtaSTSPolicyPath is the well-known location of the policy file (RFC 8461 3.2)
+	mtaSTSPolicyPath = "/.well-known/mta-sts.txt"
+	// mtaSTSMaxPolicySize caps how much of the policy file is read
+	mtaSTSMaxPolicySize = 64 * 1024
+	// mtaSTSMaxAge is the largest max_age allowed by RFC 8461
+	mtaSTSMaxAge = 31557600
+	// mtaSTSMinRecommendedAge is the shortest max_age worth having; RFC 8461
+	// recommends weeks so that policies survive an attacker-controlled window
+	mtaSTSMinRecommendedAge = 86400
+)
+
+// MTASTSOptions controls how the MTA-STS policy file is retrieved
+type MTASTSOptions struct {
+	// FetchPolicy enables the HTTPS request for the policy file
+	FetchPolicy bool
+	// BaseURL replaces https://mta-sts.<domain> when set
+	BaseURL string
+	// HTTPClient is used for the policy request, defaults to a client that
+	// refuses redirects as required by RFC 8461
+	HTTPClient *http.Client
+}
+
+// AnalyzeMTASTS checks the _mta-sts record of a domain and, if enabled, fetches
+// the policy file and verifies its mx patterns cover the collected MX hosts
+func AnalyzeMTASTS(ctx context.Context, resolver Resolver, domain string, mxHosts []string, options MTASTSOptions) *models.MTASTSResult {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	result := &models.MTASTSResult{Status: MTASTSStatusNone}
+
+	records, err := resolver.Lookup(ctx, "_mta-sts."+domain, "TXT")
+	if err != nil {
+		result.Status = MTASTSStatusTempError
+		result.Errors = append(result.Errors, fmt.Sprintf("error looking up MTA-STS record for %s: %v", domain, err))
+		return result
+	}
+
+	var stsRecords []string
+	for _, record := range records {
+		if record.RecordType == "TXT" && hasVersionTag(record.Value, "STSv1") {
+			stsRecords = append(stsRecords, record.Value)
+		}
+	}
+
+	if len(stsRecords) > 0 {
+		result.Record = stsRecords[0]
+		result.Status = MTASTSStatusValid
+		if len(stsRecords) > 1 {
+			invalidMTASTS(result, "multiple MTA-STS records published, senders will ignore the policy")
+		}
+		for _, part := range strings.Split(result.Record, ";") {
+			tag, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if strings.TrimSpace(tag) == "id" {
+				result.ID = strings.TrimSpace(value)
+			}
+		}
+		if result.ID == "" {
+			invalidMTASTS(result, "MTA-STS record has no id tag")
+		}
+	}
+
+	if !options.FetchPolicy {
+		return result
+	}
+
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = "https://mta-sts." + domain
+	}
+	result.PolicyURL = strings.TrimSuffix(baseURL, "/") + mtaSTSPolicyPath
+
+	policy, err := fetchMTASTSPolicy(ctx, options.HTTPClient, result.PolicyURL)
+	if err != nil {
+		if result.Record != "" {
+			invalidMTASTS(result, err.Error())
+		}
+		return result
+	}
+	result.Policy = policy
+
+	if result.Record == "" {
+		result.Warnings = append(result.Warnings, "policy file is published but senders ignore it without an _mta-sts TXT record")
+	}
+	checkMTASTSPolicy(result, mxHosts)
+
+	return result
+}
+
+// AnalyzeTLSRPT looks up and parses the SMTP TLS reporting record at _smtp._tls.<domain>
+func AnalyzeTLSRPT(ctx context.Context, resolver Resolver, domain string) *models.TLSRPTResult {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	result := &models.TLSRPTResult{Status: MTASTSStatusNone}
+
+	records, err := resolver.Lookup(ctx, "_smtp._tls."+domain, "TXT")
+	if err != nil {
+		result.Status = MTASTSStatusTempError
+		result.Errors = append(result.Errors, fmt.Sprintf("error looking up TLS-RPT record for %s: %v", domain, err))
+		return result
+	}
+
+	var rptRecords []string
+	for _, record := range records {
+		if record.RecordType == "TXT" && hasVersionTag(record.Value, "TLSRPTv1") {
+			rptRecords = append(rptRecords, record.Value)
+		}
+	}
+	if len(rptRecords) == 0 {
+		return result
+	}
+
+	result.Record = rptRecords[0]
+	result.Status = MTASTSStatusValid
+	if len(rptRecords) > 1 {
+		result.Status = MTASTSStatusInvalid
+		result.Errors = append(result.Errors, "multiple TLS-RPT records published")
+	}
+
+	for _, part := range strings.Split(result.Record, ";") {
+		tag, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.TrimSpace(tag) != "rua" {
+			continue
+		}
+		for _, uri := range strings.Split(value, ",") {
+			uri = strings.TrimSpace(uri)
+			lower := strings.ToLower(uri)
+			if !strings.HasPrefix(lower, "mailto:") && !strings.HasPrefix(lower, "https:") {
+				result.Status = MTASTSStatusInvalid
+				result.Errors = append(result.Errors, fmt.Sprintf("unsupported report URI %q", uri))
+				continue
+			}
+			result.ReportURIs = append(result.ReportURIs, uri)
+		}
+	}
+
+	if len(result.ReportURIs) == 0 {
+		result.Status = MTASTSStatusInvalid
+		result.Errors = append(result.Errors, "TLS-RPT record has no rua destination")
+	}
+
+	return result
+}
+
+// fetchMTASTSPolicy downloads and parses a policy file
+func fetchMTASTSPolicy(ctx context.Context, client *http.Client, policyURL string) (*models.MTASTSPolicy, error) {
+	if client == nil {
+		client = &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, policyURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating policy request: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching MTA-STS policy: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching MTA-STS policy: HTTP %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(strings.ToLower(contentType), "text/plain") {
+		return nil, fmt.Errorf("MTA-STS policy served with content type %q instead of text/plain", contentType)
+	}
+
+	return parseMTASTSPolicy(io.LimitReader(resp.Body, mtaSTSMaxPolicySize))
+}
+
+// parseMTASTSPolicy reads the key: value lines of a policy file
+func parseMTASTSPolicy(r io.Reader) (*models.MTASTSPolicy, error) {
+	policy := &models.MTASTSPolicy{MaxAge: -1}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("malformed MTA-STS policy line %q", line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "version":
+			policy.Version = value
+		case "mode":
+			policy.Mode = value
+		case "mx":
+			policy.MX = append(policy.MX, strings.ToLower(strings.TrimSuffix(value, ".")))
+		case "max_age":
+			maxAge, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max_age %q in MTA-STS policy", value)
+			}
+			policy.MaxAge = maxAge
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading MTA-STS policy: %v", err)
+	}
+
+	if policy.Version != "STSv1" {
+		return nil, fmt.Errorf("MTA-STS policy has version %q instead of STSv1", policy.Version)
+	}
+	return policy, nil
+}
+
+// checkMTASTSPolicy validates the policy fields and matches its mx patterns
+// against the MX hosts found for the domain
+func checkMTASTSPolicy(result *models.MTASTSResult, mxHosts []string) {
+	policy := result.Policy
+
+	switch policy.Mode {
+	case "enforce":
+	case "testing":
+		result.Warnings = append(result.Warnings, "policy is in testing mode, failures are reported but mail is still delivered")
+	case "none":
+		result.Warnings = append(result.Warnings, "policy mode is none, MTA-STS is disabled")
+	default:
+		invalidMTASTS(result, fmt.Sprintf("invalid policy mode %q", policy.Mode))
+	}
+
+	switch {
+	case policy.MaxAge < 0:
+		invalidMTASTS(result, "policy has no max_age")
+	case policy.MaxAge > mtaSTSMaxAge:
+		invalidMTASTS(result, fmt.Sprintf("max_age %d exceeds the maximum of %d", policy.MaxAge, mtaSTSMaxAge))
+	case policy.MaxAge < mtaSTSMinRecommendedAge:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("max_age %d is shorter than one day, cached policies expire quickly", policy.MaxAge))
+	}
+
+	if len(policy.MX) == 0 && policy.Mode != "none" {
+		invalidMTASTS(result, "policy has no mx entries")
+	}
+
+	for _, host := range mxHosts {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		matched := false
+		for _, pattern := range policy.MX {
+			if matchMTASTSPattern(pattern, host) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			result.UnmatchedMX = append(result.UnmatchedMX, host)
+		}
+	}
+
+	if len(result.UnmatchedMX) > 0 {
+		message := fmt.Sprintf("MX hosts not covered by the policy: %s", strings.Join(result.UnmatchedMX, ", "))
+		if policy.Mode == "enforce" {
+			// Senders refuse to deliver to these hosts
+			invalidMTASTS(result, message)
+		} else {
+			result.Warnings = append(result.Warnings, message)
+		}
+	}
+}
+
+// matchMTASTSPattern matches a host against an mx pattern, where a leading
+// "*." stands for exactly one label
+func matchMTASTSPattern(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		_, parent, found := strings.Cut(host, ".")
+		return found && parent == pattern[2:]
+	}
+	return pattern == host
+}
+
+// invalidMTASTS marks the result invalid and records why
+func invalidMTASTS(result *models.MTASTSResult, message string) {
+	result.Status = MTASTSStatusInvalid
+	result.Errors = appendUnique(result.Errors, message)
+}
+
+// mxHostsFromRecords returns the exchange hosts of the MX records in a record set
+func mxHostsFromRecords(records []models.DNSResponse) []string {
+	var hosts []string
+	for _, record := range records {
+		if record.RecordType != "MX" {
+			continue
+		}
+		fields := strings.Fields(record.Value)
+		if len(fields) != 2 || fields[1] == "." {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(fields[1]), ".")
+		if !containsString(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeMTASTS(t *testing.T) {
+	testCases := []struct {
+		name        string
+		records     fakeResolver
+		policy      string
+		contentType string
+		mxHosts     []string
+		status      string
+		mode        string
+		unmatchedMX []string
+		warnings    int
+	}{
+		{
+			name:    "No record and no policy",
+			records: fakeResolver{},
+			status:  MTASTSStatusNone,
+		},
+		{
+			name:        "Enforced policy covering every MX",
+			records:     fakeResolver{"_mta-sts.example.com TXT": {"v=STSv1; id=20240101T000000"}},
+			policy:      "version: STSv1\nmode: enforce\nmx: mail.example.com\nmx: *.mx.example.net\nmax_age: 604800\n",
+			contentType: "text/plain",
+			mxHosts:     []string{"mail.example.com", "eu1.mx.example.net"},
+			status:      MTASTSStatusValid,
+			mode:        "enforce",
+		},
+		{
+			name:        "Enforced policy missing an MX",
+			records:     fakeResolver{"_mta-sts.example.com TXT": {"v=STSv1; id=1"}},
+			policy:      "version: STSv1\nmode: enforce\nmx: *.example.com\nmax_age: 604800\n",
+			contentType: "text/plain",
+			mxHosts:     []string{"mx1.example.com", "a.b.example.com", "mx.backup.net"},
+			status:      MTASTSStatusInvalid,
+			mode:        "enforce",
+			unmatchedMX: []string{"a.b.example.com", "mx.backup.net"},
+		},
+		{
+			name:        "Testing mode with short max_age",
+			records:     fakeResolver{"_mta-sts.example.com TXT": {"v=STSv1; id=1"}},
+			policy:      "version: STSv1\nmode: testing\nmx: mx.backup.net\nmax_age: 3600\n",
+			contentType: "text/plain; charset=utf-8",
+			mxHosts:     []string{"mx.backup.net"},
+			status:      MTASTSStatusValid,
+			mode:        "testing",
+			warnings:    2,
+		},
+		{
+			name:        "Policy served with the wrong content type",
+			records:     fakeResolver{"_mta-sts.example.com TXT": {"v=STSv1; id=1"}},
+			policy:      "version: STSv1\nmode: enforce\nmx: mx.example.com\nmax_age: 604800\n",
+			contentType: "text/html",
+			status:      MTASTSStatusInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.policy == "" || r.URL.Path != mtaSTSPolicyPath {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				fmt.Fprint(w, tc.policy)
+			}))
+			defer server.Close()
+
+			options := MTASTSOptions{FetchPolicy: true, BaseURL: server.URL}
+			result := AnalyzeMTASTS(context.Background(), tc.records, "example.com.", tc.mxHosts, options)
+
+			if result.Status != tc.status {
+				t.Errorf("Expected status %s, got %s (errors: %v)", tc.status, result.Status, result.Errors)
+			}
+			if tc.mode != "" && (result.Policy == nil || result.Policy.Mode != tc.mode) {
+				t.Errorf("Expected policy mode %s, got %+v", tc.mode, result.Policy)
+			}
+			if !reflect.DeepEqual(result.UnmatchedMX, tc.unmatchedMX) {
+				t.Errorf("Expected unmatched MX %v, got %v", tc.unmatchedMX, result.UnmatchedMX)
+			}
+			if len(result.Warnings) != tc.warnings {
+				t.Errorf("Expected %d warnings, got %v", tc.warnings, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTLSRPT(t *testing.T) {
+	records := fakeResolver{
+		"_smtp._tls.example.com TXT": {"v=TLSRPTv1; rua=mailto:tlsrpt@example.com,https://reports.example.net/tlsrpt"},
+	}
+
+	result := AnalyzeTLSRPT(context.Background(), records, "example.com")
+	expected := []string{"mailto:tlsrpt@example.com", "https://reports.example.net/tlsrpt"}
+
+	if result.Status != MTASTSStatusValid {
+		t.Errorf("Expected status %s, got %s (errors: %v)", MTASTSStatusValid, result.Status, result.Errors)
+	}
+	if !reflect.DeepEqual(result.ReportURIs, expected) {
+		t.Errorf("Expected report URIs %v, got %v", expected, result.ReportURIs)
+	}
+}
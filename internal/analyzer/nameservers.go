@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// nameserverPort is the port authoritative servers are probed on
+const nameserverPort = "53"
+
+// NameserverProber sends queries straight to an authoritative nameserver
+// rather than through a recursive resolver. *dns.Client satisfies it.
+type NameserverProber interface {
+	FingerprintNameserver(ctx context.Context, zone, server string) models.NameserverFingerprint
+	ProbeExposure(ctx context.Context, zone, server string) models.NameserverExposure
+}
+
+// NameserverOptions selects the probes sent to each authoritative nameserver
+type NameserverOptions struct {
+	// Fingerprint identifies the nameserver software
+	Fingerprint bool
+	// Exposure checks for open recursion and reflection amplification
+	Exposure bool
+}
+
+// AnalyzeNameservers resolves the addresses of the domain's NS hosts and runs
+// the requested probes against each of them
+func AnalyzeNameservers(ctx context.Context, resolver Resolver, prober NameserverProber, domain string, records []models.DNSResponse, options NameserverOptions) []models.Nameserver {
+	var hosts []string
+	for _, record := range records {
+		if record.RecordType != "NS" {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(record.Value), ".")
+		if !containsString(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+
+	nameservers := make([]models.Nameserver, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+
+			nameserver := models.Nameserver{Host: host}
+			for _, recordType := range []string{"A", "AAAA"} {
+				responses, err := resolver.Lookup(ctx, host, recordType)
+				if err != nil {
+					continue
+				}
+				for _, response := range responses {
+					if response.RecordType == recordType {
+						nameserver.Addresses = append(nameserver.Addresses, response.Value)
+					}
+				}
+			}
+
+			if len(nameserver.Addresses) > 0 {
+				server := net.JoinHostPort(nameserver.Addresses[0], nameserverPort)
+				if options.Fingerprint {
+					result := prober.FingerprintNameserver(ctx, domain, server)
+					nameserver.Fingerprint = &result
+				}
+				if options.Exposure {
+					result := prober.ProbeExposure(ctx, domain, server)
+					nameserver.Exposure = &result
+				}
+			}
+
+			nameservers[i] = nameserver
+		}(i, host)
+	}
+	wg.Wait()
+
+	return nameservers
+}
+
+// nameserverRecords turns nameserver fingerprints into pseudo-records so that
+// signatures can match on them. CHAOS records carry "<query> <answer>" values,
+// NSID records the decoded NSID.
+func nameserverRecords(nameservers []models.Nameserver) []models.DNSResponse {
+	var records []models.DNSResponse
+	for _, nameserver := range nameservers {
+		fingerprint := nameserver.Fingerprint
+		if fingerprint == nil {
+			continue
+		}
+
+		owner := nameserver.Host + "."
+		for _, answer := range []struct{ query, value string }{
+			{"version.bind", fingerprint.VersionBind},
+			{"hostname.bind", fingerprint.HostnameBind},
+			{"id.server", fingerprint.IDServer},
+		} {
+			if answer.value != "" {
+				records = append(records, models.DNSResponse{
+					Domain:     owner,
+					Name:       owner,
+					RecordType: "CHAOS",
+					Value:      answer.query + " " + answer.value,
+				})
+			}
+		}
+		if fingerprint.NSID != "" {
+			records = append(records, models.DNSResponse{
+				Domain:     owner,
+				Name:       owner,
+				RecordType: "NSID",
+				Value:      fingerprint.NSID,
+			})
+		}
+	}
+	return records
+}
@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// fakeProber returns canned fingerprints keyed by server address
+type fakeProber struct {
+	mu          sync.Mutex
+	probed      []string
+	fingerprint map[string]models.NameserverFingerprint
+}
+
+func (f *fakeProber) FingerprintNameserver(ctx context.Context, zone, server string) models.NameserverFingerprint {
+	f.mu.Lock()
+	f.probed = append(f.probed, server)
+	f.mu.Unlock()
+
+	result := f.fingerprint[server]
+	result.Server = server
+	return result
+}
+
+func (f *fakeProber) ProbeExposure(ctx context.Context, zone, server string) models.NameserverExposure {
+	f.mu.Lock()
+	f.probed = append(f.probed, server)
+	f.mu.Unlock()
+
+	return models.NameserverExposure{Server: server}
+}
+
+func TestAnalyzeNameservers(t *testing.T) {
+	resolver := fakeResolver{
+		"ns1.example.com A":    {"192.0.2.53"},
+		"ns1.example.com AAAA": {"2001:db8::53"},
+		"ns2.example.net A":    {"198.51.100.53"},
+	}
+	records := []models.DNSResponse{
+		{Domain: "example.com.", RecordType: "NS", Value: "ns2.example.net."},
+		{Domain: "example.com.", RecordType: "NS", Value: "NS1.example.com."},
+		{Domain: "example.com.", RecordType: "NS", Value: "ns1.example.com."},
+		{Domain: "example.com.", RecordType: "A", Value: "192.0.2.1"},
+	}
+	prober := &fakeProber{fingerprint: map[string]models.NameserverFingerprint{
+		"192.0.2.53:53":    {VersionBind: "9.18.24", NSID: "fra1", Software: "BIND"},
+		"198.51.100.53:53": {IDServer: "edge-7"},
+	}}
+
+	nameservers := AnalyzeNameservers(context.Background(), resolver, prober, "example.com", records, NameserverOptions{Fingerprint: true})
+
+	if len(nameservers) != 2 {
+		t.Fatalf("Expected 2 nameservers, got %+v", nameservers)
+	}
+	if nameservers[0].Host != "ns1.example.com" || len(nameservers[0].Addresses) != 2 {
+		t.Errorf("Unexpected first nameserver %+v", nameservers[0])
+	}
+	if nameservers[0].Fingerprint == nil || nameservers[0].Fingerprint.Software != "BIND" {
+		t.Errorf("Expected ns1 to be fingerprinted as BIND, got %+v", nameservers[0].Fingerprint)
+	}
+	if len(prober.probed) != 2 {
+		t.Errorf("Expected each nameserver to be probed once on its first address, got %v", prober.probed)
+	}
+
+	pseudo := nameserverRecords(nameservers)
+	expected := map[string]bool{
+		"ns1.example.com. CHAOS version.bind 9.18.24": true,
+		"ns1.example.com. NSID fra1":                  true,
+		"ns2.example.net. CHAOS id.server edge-7":     true,
+	}
+	if len(pseudo) != len(expected) {
+		t.Fatalf("Expected %d pseudo-records, got %+v", len(expected), pseudo)
+	}
+	for _, record := range pseudo {
+		if key := record.Domain + " " + record.RecordType + " " + record.Value; !expected[key] {
+			t.Errorf("Unexpected pseudo-record %q", key)
+		}
+	}
+
+	if nameservers[0].Exposure != nil {
+		t.Errorf("Expected no exposure probe unless requested")
+	}
+
+	// Without any probes the nameservers are only resolved
+	prober.probed = nil
+	nameservers = AnalyzeNameservers(context.Background(), resolver, prober, "example.com", records, NameserverOptions{})
+	if len(prober.probed) != 0 || nameservers[1].Fingerprint != nil {
+		t.Errorf("Expected no probes, got %v", prober.probed)
+	}
+}
+
+func TestProbeFindings(t *testing.T) {
+	nameservers := []models.Nameserver{
+		{Host: "ns1.example.com", Exposure: &models.NameserverExposure{Findings: []models.Finding{{ID: "ns-open-recursion"}}}},
+		{Host: "ns2.example.com"},
+	}
+	delegation := &models.DelegationResult{Findings: []models.Finding{{ID: "delegation-lame"}}}
+
+	var ids []string
+	for _, finding := range probeFindings(nameservers, delegation) {
+		ids = append(ids, finding.ID)
+	}
+	if expected := []string{"ns-open-recursion", "delegation-lame"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+	if findings := probeFindings(nil, nil); findings != nil {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
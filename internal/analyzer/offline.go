@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// maxCNAMEChain bounds how many CNAMEs recordResolver follows
+const maxCNAMEChain = 8
+
+// errNotAvailableOffline is returned for lookups the records can't answer,
+// so analyzers treat them as failed lookups rather than missing records
+var errNotAvailableOffline = errors.New("not available offline")
+
+// recordResolver answers lookups from a fixed set of records, following
+// CNAMEs within the set the way a recursive resolver would
+type recordResolver struct {
+	// zone is the analyzed domain without the trailing dot. The records are
+	// taken to be complete for it and the names below it.
+	zone string
+	// records is keyed by lowercase owner name without the trailing dot and
+	// record type
+	records map[string][]models.DNSResponse
+	// owners holds every owner name with records, without the trailing dot
+	owners map[string]bool
+}
+
+// newRecordResolver indexes records by owner name and type
+func newRecordResolver(zone string, records []models.DNSResponse) *recordResolver {
+	resolver := &recordResolver{
+		zone:    strings.TrimSuffix(strings.ToLower(zone), "."),
+		records: make(map[string][]models.DNSResponse),
+		owners:  make(map[string]bool),
+	}
+	for _, record := range records {
+		key := recordKey(record.Owner(), record.RecordType)
+		if !containsRecord(resolver.records[key], record) {
+			resolver.records[key] = append(resolver.records[key], record)
+		}
+		resolver.owners[strings.TrimSuffix(strings.ToLower(record.Owner()), ".")] = true
+	}
+	return resolver
+}
+
+// Lookup returns the records of a type at name, preceded by any CNAMEs
+// leading there. Names in the zone without records answer with nothing, like
+// NXDOMAIN. Names outside it that the records don't cover, such as another
+// domain's SPF include, fail with errNotAvailableOffline.
+func (r *recordResolver) Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error) {
+	queried := strings.ToLower(name)
+	if !strings.HasSuffix(queried, ".") {
+		queried += "."
+	}
+	recordType = strings.ToUpper(recordType)
+
+	var responses []models.DNSResponse
+	owner := queried
+	for i := 0; i <= maxCNAMEChain; i++ {
+		if !r.covers(owner) {
+			return nil, errNotAvailableOffline
+		}
+		if found := r.records[recordKey(owner, recordType)]; len(found) > 0 {
+			return append(responses, answer(queried, found)...), nil
+		}
+		cnames := r.records[recordKey(owner, "CNAME")]
+		if len(cnames) == 0 || recordType == "CNAME" {
+			break
+		}
+		responses = append(responses, answer(queried, cnames[:1])...)
+		owner = strings.ToLower(cnames[0].Value)
+	}
+	return responses, nil
+}
+
+// covers reports whether the records can answer for a name: it is in the
+// zone, or the records include some of its records
+func (r *recordResolver) covers(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == r.zone || strings.HasSuffix(name, "."+r.zone) {
+		return true
+	}
+	return r.owners[name]
+}
+
+// answer copies records as the answer to a query for queried
+func answer(queried string, records []models.DNSResponse) []models.DNSResponse {
+	answers := make([]models.DNSResponse, 0, len(records))
+	for _, record := range records {
+		record.Name = record.Owner()
+		record.Domain = queried
+		answers = append(answers, record)
+	}
+	return answers
+}
+
+// recordKey builds the index key for an owner name and record type
+func recordKey(owner, recordType string) string {
+	return strings.TrimSuffix(strings.ToLower(owner), ".") + " " + recordType
+}
+
+// containsRecord reports whether records already holds a record with the same
+// type and value
+func containsRecord(records []models.DNSResponse, record models.DNSResponse) bool {
+	for _, existing := range records {
+		if existing.RecordType == record.RecordType && existing.Value == record.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/dns"
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/Elite-Security-Systems/radar/internal/utils"
+)
+
+func TestRecordResolver(t *testing.T) {
+	resolver := newRecordResolver("example.com", []models.DNSResponse{
+		{Domain: "example.com.", RecordType: "CNAME", Value: "mta-sts.Provider.example."},
+		{Domain: "mta-sts.provider.example.", Name: "mta-sts.provider.example.", RecordType: "A", Value: "192.0.2.1"},
+		{Domain: "example.com.", RecordType: "A", Value: "192.0.2.2"},
+		{Domain: "example.com.", RecordType: "A", Value: "192.0.2.2"},
+	})
+
+	answers, _ := resolver.Lookup(context.Background(), "Example.com", "A")
+	if len(answers) != 1 || answers[0].Value != "192.0.2.2" || answers[0].Domain != "example.com." {
+		t.Errorf("Expected the deduplicated A record, got %v", answers)
+	}
+
+	// CNAMEs are followed, and every answer is for the queried name
+	resolver = newRecordResolver("example.com.", []models.DNSResponse{
+		{Domain: "_mta-sts.example.com.", RecordType: "CNAME", Value: "_mta-sts.Provider.example."},
+		{Domain: "_mta-sts.provider.example.", RecordType: "TXT", Value: "v=STSv1; id=1"},
+	})
+	answers, _ = resolver.Lookup(context.Background(), "_mta-sts.example.com.", "TXT")
+	if len(answers) != 2 || answers[1].Value != "v=STSv1; id=1" || answers[1].Owner() != "_mta-sts.provider.example." || answers[1].Domain != "_mta-sts.example.com." {
+		t.Errorf("Expected the CNAME and the TXT record, got %v", answers)
+	}
+
+	if answers, err := resolver.Lookup(context.Background(), "missing.example.com.", "TXT"); err != nil || len(answers) != 0 {
+		t.Errorf("Expected no answer, got %v (%v)", answers, err)
+	}
+
+	// Names outside the zone that the records don't cover can't be answered
+	if _, err := resolver.Lookup(context.Background(), "_spf.provider.example.", "TXT"); err != errNotAvailableOffline {
+		t.Errorf("Expected errNotAvailableOffline, got %v", err)
+	}
+}
+
+func TestAnalyzeRecords(t *testing.T) {
+	zone, records, err := dns.ParseZone(strings.NewReader(`$ORIGIN example.com.
+$TTL 3600
+@       IN SOA ns1.example.net. hostmaster.example.com. 2024010101 7200 900 1209600 300
+@       IN MX  10 aspmx.l.google.com.
+@       IN TXT "v=spf1 ip4:192.0.2.0/24 include:_spf.google.com -all"
+_dmarc  IN TXT "v=DMARC1; p=none; rua=mailto:reports@dmarc.example.net"
+*       IN MX  10 catchall.example.net.
+`), "", "example.com.zone")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Google Workspace", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "google.com"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules, err := NewRuleSet(models.RuleFile{Rules: []models.Rule{
+		{ID: "wildcard-mx", Severity: models.SeverityMedium, Description: "wildcard MX", Condition: models.Condition{
+			Match: &models.Pattern{Prefix: "*.", Field: models.FieldName}, RecordTypes: []string{"MX"},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := AnalyzeRecords(Config{Domain: strings.TrimSuffix(zone, "."), Rules: rules, MTASTS: MTASTSOptions{FetchPolicy: true}}, matcher, records)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Domain != "example.com" || result.Input != "" {
+		t.Errorf("Unexpected domain %q (input %q)", result.Domain, result.Input)
+	}
+	if len(result.DetectedTechnologies) != 1 || result.DetectedTechnologies[0].Name != "Google Workspace" {
+		t.Errorf("Expected Google Workspace, got %v", result.DetectedTechnologies)
+	}
+	if result.DMARC.Policy != "none" {
+		t.Errorf("Expected DMARC policy none, got %q", result.DMARC.Policy)
+	}
+	// The include points outside the zone, which is a lookup failure offline
+	// rather than a missing record
+	if result.SPF.Status != SPFStatusTempError {
+		t.Errorf("Expected a temperror for the include outside the zone, got %s %v", result.SPF.Status, result.SPF.Errors)
+	}
+	for _, warning := range result.DMARC.Warnings {
+		if strings.Contains(warning, "has not authorized") {
+			t.Errorf("Expected no authorization warning for the report destination, got %q", warning)
+		}
+	}
+	if result.MTASTS.Status != MTASTSStatusNone {
+		t.Errorf("Expected no MTA-STS, got %s", result.MTASTS.Status)
+	}
+	// The zone has no CAA or DNSKEY records either
+	var ids []string
+	for _, finding := range result.Findings {
+		ids = append(ids, finding.ID)
+	}
+	if expected := []string{"wildcard-mx", "caa-missing", "dnssec-missing"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected findings %v, got %v", expected, ids)
+	}
+	if result.Trace != nil || result.Nameservers != nil {
+		t.Errorf("Expected no network results")
+	}
+}
+
+func TestAnalyzeLegacyResult(t *testing.T) {
+	// Results saved by older releases only need their domain and records
+	results, err := utils.LoadResults("../utils/testdata/legacy-result.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Google Workspace", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "google.com"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := AnalyzeRecords(Config{Domain: results[0].Domain}, matcher, results[0].AllRecords)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.DetectedTechnologies) != 1 || result.DetectedTechnologies[0].Name != "Google Workspace" {
+		t.Errorf("Expected Google Workspace from the saved records, got %+v", result.DetectedTechnologies)
+	}
+	if result.SPF == nil || result.SPF.Record != "v=spf1 include:_spf.google.com ~all" {
+		t.Errorf("Expected the saved SPF record to be analyzed, got %+v", result.SPF)
+	}
+}
@@ -0,0 +1,279 @@
+package analyzer
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// compiledPattern is a signature pattern ready to match: a compiled regex,
+// literal text folded as its kind requires, a parsed network or a number
+// range
+type compiledPattern struct {
+	kind    string
+	text    string
+	fold    bool
+	re      *regexp.Regexp
+	network *net.IPNet
+	number  *models.Range
+	ttl     *models.Range
+	token   int
+
+	weight int
+	field  string
+	source string
+}
+
+// compilePattern validates a pattern and prepares it for matching
+func compilePattern(pattern models.Pattern) (compiledPattern, error) {
+	if err := pattern.Validate(); err != nil {
+		return compiledPattern{}, err
+	}
+
+	kind, text := pattern.Kind()
+	compiled := compiledPattern{
+		kind:   kind,
+		text:   text,
+		number: pattern.Number,
+		ttl:    pattern.TTL,
+		token:  pattern.Token,
+		weight: pattern.Weight,
+		field:  pattern.Field,
+		source: pattern.String(),
+	}
+
+	switch kind {
+	case models.PatternRegex:
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return compiledPattern{}, err
+		}
+		compiled.re = re
+
+	case models.PatternCIDR:
+		_, network, err := net.ParseCIDR(text)
+		if err != nil {
+			return compiledPattern{}, err
+		}
+		compiled.network = network
+
+	case models.PatternSuffix:
+		// Host names ignore case and the suffix is always whole labels
+		compiled.fold = true
+		compiled.text = strings.TrimSuffix(strings.TrimPrefix(text, "."), ".")
+
+	case models.PatternExact, models.PatternPrefix, models.PatternContains:
+		compiled.fold = pattern.IgnoreCase || pattern.Field == models.FieldName
+	}
+	if compiled.fold {
+		compiled.text = strings.ToLower(compiled.text)
+	}
+
+	return compiled, nil
+}
+
+// indexed reports whether the pattern is found through a literalIndex
+// rather than tried against every record. The index is keyed by whole
+// values, so patterns on a single token are always tried directly.
+func (p compiledPattern) indexed() bool {
+	if p.token > 0 {
+		return false
+	}
+	switch p.kind {
+	case models.PatternExact, models.PatternPrefix, models.PatternSuffix, models.PatternCIDR:
+		return true
+	}
+	return false
+}
+
+// match runs a pattern against the record field it applies to, first as is
+// and then without a trailing dot, and returns the submatches of the first
+// hit. Literal kinds return the whole subject as their only submatch.
+func (p compiledPattern) match(record models.DNSResponse) []string {
+	if p.ttl != nil && !p.ttl.Contains(record.TTL) {
+		return nil
+	}
+
+	subject := record.Value
+	if p.field == models.FieldName {
+		subject = strings.ToLower(record.Owner())
+	}
+	if p.token > 0 {
+		tokens := strings.Fields(subject)
+		if p.token > len(tokens) {
+			return nil
+		}
+		subject = tokens[p.token-1]
+	}
+
+	match := p.matchSubject(subject)
+	if normalized := strings.TrimSuffix(subject, "."); match == nil && normalized != subject {
+		match = p.matchSubject(normalized)
+	}
+	return match
+}
+
+// matchSubject matches one candidate string
+func (p compiledPattern) matchSubject(subject string) []string {
+	if p.kind == models.PatternRegex {
+		return p.re.FindStringSubmatch(subject)
+	}
+
+	folded := subject
+	if p.fold {
+		folded = strings.ToLower(subject)
+	}
+
+	var matched bool
+	switch p.kind {
+	case models.PatternTTL:
+		matched = true
+	case models.PatternExact:
+		matched = folded == p.text
+	case models.PatternPrefix:
+		matched = strings.HasPrefix(folded, p.text)
+	case models.PatternContains:
+		matched = strings.Contains(folded, p.text)
+	case models.PatternSuffix:
+		matched = hasLabelSuffix(folded, p.text)
+	case models.PatternCIDR:
+		ip := net.ParseIP(subject)
+		matched = ip != nil && p.network.Contains(ip)
+	case models.PatternNumber:
+		n, err := strconv.ParseUint(subject, 10, 32)
+		matched = err == nil && p.number.Contains(uint32(n))
+	}
+	if !matched {
+		return nil
+	}
+	return []string{subject}
+}
+
+// hasLabelSuffix reports whether subject is suffix or ends with it on a
+// label boundary. A space also counts as a boundary so that values such as
+// "10 mx.example.net" match by their host name.
+func hasLabelSuffix(subject, suffix string) bool {
+	if !strings.HasSuffix(subject, suffix) {
+		return false
+	}
+	if len(subject) == len(suffix) {
+		return true
+	}
+	boundary := subject[len(subject)-len(suffix)-1]
+	return boundary == '.' || boundary == ' '
+}
+
+// patternRef identifies a pattern of a signature in a Matcher
+type patternRef struct {
+	signature int
+	pattern   int
+}
+
+// literalIndex finds the exact, prefix, suffix and CIDR patterns that may
+// match a string with map lookups instead of trying each pattern in turn.
+// Keys are folded to lowercase; candidates are verified with match, which
+// applies each pattern's own case rules and TTL range.
+type literalIndex struct {
+	exact    map[string][]patternRef
+	suffix   map[string][]patternRef
+	prefix   map[string][]patternRef
+	networks map[string][]patternRef
+
+	prefixLengths []int
+	maskLengths   []int
+}
+
+// newLiteralIndex creates an empty index
+func newLiteralIndex() *literalIndex {
+	return &literalIndex{
+		exact:    make(map[string][]patternRef),
+		suffix:   make(map[string][]patternRef),
+		prefix:   make(map[string][]patternRef),
+		networks: make(map[string][]patternRef),
+	}
+}
+
+// add indexes a pattern under its literal text or network
+func (x *literalIndex) add(pattern compiledPattern, ref patternRef) {
+	key := strings.ToLower(pattern.text)
+	switch pattern.kind {
+	case models.PatternExact:
+		x.exact[key] = append(x.exact[key], ref)
+	case models.PatternSuffix:
+		x.suffix[key] = append(x.suffix[key], ref)
+	case models.PatternPrefix:
+		x.prefix[key] = append(x.prefix[key], ref)
+		x.prefixLengths = appendLength(x.prefixLengths, len(key))
+	case models.PatternCIDR:
+		ones, _ := pattern.network.Mask.Size()
+		x.networks[pattern.network.String()] = append(x.networks[pattern.network.String()], ref)
+		x.maskLengths = appendLength(x.maskLengths, ones)
+	}
+}
+
+// lookup adds the patterns that may match subject, with and without its
+// trailing dot, to candidates
+func (x *literalIndex) lookup(subject string, candidates map[patternRef]bool) {
+	subjects := []string{subject}
+	if normalized := strings.TrimSuffix(subject, "."); normalized != subject {
+		subjects = append(subjects, normalized)
+	}
+
+	for _, s := range subjects {
+		folded := strings.ToLower(s)
+		mark(candidates, x.exact[folded])
+
+		// Every label suffix of the subject, including the whole subject
+		mark(candidates, x.suffix[folded])
+		for i := 0; i < len(folded); i++ {
+			if folded[i] == '.' || folded[i] == ' ' {
+				mark(candidates, x.suffix[folded[i+1:]])
+			}
+		}
+
+		for _, length := range x.prefixLengths {
+			if length <= len(folded) {
+				mark(candidates, x.prefix[folded[:length]])
+			}
+		}
+	}
+
+	if len(x.maskLengths) == 0 {
+		return
+	}
+	ip := net.ParseIP(subject)
+	if ip == nil {
+		return
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, ones := range x.maskLengths {
+		if ones > len(ip)*8 {
+			continue
+		}
+		mask := net.CIDRMask(ones, len(ip)*8)
+		network := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		mark(candidates, x.networks[network.String()])
+	}
+}
+
+// mark adds refs to a candidate set
+func mark(candidates map[patternRef]bool, refs []patternRef) {
+	for _, ref := range refs {
+		candidates[ref] = true
+	}
+}
+
+// appendLength adds a length to a list of distinct lengths
+func appendLength(lengths []int, length int) []int {
+	for _, existing := range lengths {
+		if existing == length {
+			return lengths
+		}
+	}
+	return append(lengths, length)
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// Redetect runs the matcher over a saved result's records again and replaces
+// its detections, returning what changed. Results saved without allRecords
+// can't be redetected; ok is false for them and the result is left alone.
+func Redetect(result *models.Result, matcher *Matcher, minConfidence int) (diff models.DetectionDiff, ok bool) {
+	diff.Domain = result.Domain
+	if len(result.AllRecords) == 0 {
+		return diff, false
+	}
+
+	detected := FilterByConfidence(matcher.Detect(result.AllRecords), minConfidence)
+	diff = CompareDetections(result.DetectedTechnologies, detected)
+	diff.Domain = result.Domain
+	result.DetectedTechnologies = detected
+	return diff, true
+}
+
+// CompareDetections lists the technologies added, removed or detected with a
+// different confidence between two sets of detections. Names are compared
+// case-insensitively.
+func CompareDetections(before, after []models.DetectedTechnology) models.DetectionDiff {
+	var diff models.DetectionDiff
+
+	previous := make(map[string]models.DetectedTechnology, len(before))
+	for _, tech := range before {
+		previous[strings.ToLower(tech.Name)] = tech
+	}
+	current := make(map[string]bool, len(after))
+	for _, tech := range after {
+		key := strings.ToLower(tech.Name)
+		current[key] = true
+
+		old, found := previous[key]
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, tech.Name)
+		case old.Confidence != tech.Confidence && old.Confidence != 0:
+			// Detections saved before confidence scores have none to compare
+			diff.Changed = append(diff.Changed, models.ConfidenceChange{Name: tech.Name, Before: old.Confidence, After: tech.Confidence})
+		}
+	}
+	for _, tech := range before {
+		if !current[strings.ToLower(tech.Name)] {
+			diff.Removed = append(diff.Removed, tech.Name)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/Elite-Security-Systems/radar/internal/utils"
+)
+
+func TestRedetect(t *testing.T) {
+	matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Google Workspace", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "google.com"}}},
+		{Name: "Mimecast", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "mimecast.com"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result := &models.Result{
+		Domain: "example.com",
+		AllRecords: []models.DNSResponse{
+			{Domain: "example.com.", RecordType: "MX", Value: "aspmx.l.google.com."},
+			{Domain: "example.com.", RecordType: "MX", Value: "eu-smtp-inbound-1.mimecast.com."},
+		},
+		DetectedTechnologies: []models.DetectedTechnology{
+			{Name: "google workspace", Confidence: 40},
+			{Name: "Legacy Mail", Confidence: 80},
+		},
+	}
+
+	diff, ok := Redetect(result, matcher, 0)
+	if !ok {
+		t.Fatalf("Expected the result to be redetected")
+	}
+	expected := models.DetectionDiff{
+		Domain:  "example.com",
+		Added:   []string{"Mimecast"},
+		Removed: []string{"Legacy Mail"},
+		Changed: []models.ConfidenceChange{{Name: "Google Workspace", Before: 40, After: result.DetectedTechnologies[0].Confidence}},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, diff)
+	}
+	if len(result.DetectedTechnologies) != 2 {
+		t.Errorf("Expected the detections to be replaced, got %v", result.DetectedTechnologies)
+	}
+
+	// Running the same signatures again changes nothing
+	if diff, _ := Redetect(result, matcher, 0); !diff.Empty() {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+
+	saved := &models.Result{Domain: "quiet.example", DetectedTechnologies: []models.DetectedTechnology{{Name: "Mimecast"}}}
+	if _, ok := Redetect(saved, matcher, 0); ok || len(saved.DetectedTechnologies) != 1 {
+		t.Errorf("Expected a result without records to be left alone")
+	}
+}
+
+func TestRedetectLegacyResult(t *testing.T) {
+	matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Google Workspace", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "google.com"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	results, err := utils.LoadResults("../utils/testdata/legacy-result.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Old detections have no confidence, so only additions and removals count
+	diff, ok := Redetect(&results[0], matcher, 0)
+	expected := models.DetectionDiff{Domain: "example.com", Removed: []string{"Legacy Mail Relay"}}
+	if !ok || !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, diff)
+	}
+}
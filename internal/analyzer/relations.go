@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// checkRelations reports implies and excludes entries that don't name a
+// signature in the matcher
+func (m *Matcher) checkRelations() []string {
+	var invalid []string
+	for _, compiled := range m.signatures {
+		sig := compiled.signature
+		for _, name := range sig.Implies {
+			if _, ok := m.names[name]; !ok {
+				invalid = append(invalid, fmt.Sprintf("signature %s: implies unknown technology %q", sig.Name, name))
+			}
+		}
+		for _, name := range sig.Excludes {
+			if _, ok := m.names[name]; !ok {
+				invalid = append(invalid, fmt.Sprintf("signature %s: excludes unknown technology %q", sig.Name, name))
+			}
+		}
+	}
+	return invalid
+}
+
+// resolveRelations drops the weaker of mutually exclusive detections and then
+// adds the technologies the remaining ones imply
+func (m *Matcher) resolveRelations(detected []models.DetectedTechnology) []models.DetectedTechnology {
+	return m.addImplied(m.resolveExclusions(detected))
+}
+
+// resolveExclusions drops the detection with the lower confidence from every
+// pair that excludes each other. On a tie the earlier detection is kept.
+func (m *Matcher) resolveExclusions(detected []models.DetectedTechnology) []models.DetectedTechnology {
+	dropped := make(map[int]bool)
+	for i := range detected {
+		for j := i + 1; j < len(detected); j++ {
+			if dropped[i] || dropped[j] || !m.excludes(detected[i].Name, detected[j].Name) {
+				continue
+			}
+			if detected[j].Confidence > detected[i].Confidence {
+				dropped[i] = true
+			} else {
+				dropped[j] = true
+			}
+		}
+	}
+	if len(dropped) == 0 {
+		return detected
+	}
+
+	kept := make([]models.DetectedTechnology, 0, len(detected)-len(dropped))
+	for i, tech := range detected {
+		if !dropped[i] {
+			kept = append(kept, tech)
+		}
+	}
+	return kept
+}
+
+// addImplied adds the technologies implied by the detections, following
+// implications transitively. Implied technologies that aren't detected
+// themselves are marked as inferred and take the highest confidence of the
+// detections implying them. A technology excluded by a detection is never
+// inferred.
+func (m *Matcher) addImplied(detected []models.DetectedTechnology) []models.DetectedTechnology {
+	positions := make(map[string]int)
+	for i, tech := range detected {
+		positions[tech.Name] = i
+	}
+
+	// Confidence only grows and provenance only accumulates, so this settles
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(detected); i++ {
+			index, ok := m.names[detected[i].Name]
+			if !ok {
+				continue
+			}
+			for _, name := range m.signatures[index].signature.Implies {
+				implied, ok := m.names[name]
+				if !ok || name == detected[i].Name {
+					continue
+				}
+
+				position, exists := positions[name]
+				if !exists {
+					if m.excludedByAny(name, detected) {
+						continue
+					}
+					tech := newDetection(m.signatures[implied].signature)
+					tech.Inferred = true
+					position = len(detected)
+					positions[name] = position
+					detected = append(detected, tech)
+					changed = true
+				}
+
+				// Provenance never runs in a circle back to where it started
+				if impliedThrough(detected, positions, detected[i].Name, name, map[string]bool{}) {
+					continue
+				}
+
+				tech := &detected[position]
+				if !containsString(tech.ImpliedBy, detected[i].Name) {
+					tech.ImpliedBy = append(tech.ImpliedBy, detected[i].Name)
+					changed = true
+				}
+				if tech.Inferred && detected[i].Confidence > tech.Confidence {
+					tech.Confidence = detected[i].Confidence
+					changed = true
+				}
+			}
+		}
+	}
+
+	return detected
+}
+
+// impliedThrough reports whether the detection name is implied, directly or
+// through other detections, by ancestor
+func impliedThrough(detected []models.DetectedTechnology, positions map[string]int, name, ancestor string, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+
+	for _, parent := range detected[positions[name]].ImpliedBy {
+		if parent == ancestor || impliedThrough(detected, positions, parent, ancestor, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludes reports whether either of two technologies excludes the other
+func (m *Matcher) excludes(a, b string) bool {
+	indexA, okA := m.names[a]
+	indexB, okB := m.names[b]
+	if !okA || !okB {
+		return false
+	}
+	return containsString(m.signatures[indexA].signature.Excludes, b) ||
+		containsString(m.signatures[indexB].signature.Excludes, a)
+}
+
+// excludedByAny reports whether any detection excludes the named technology
+func (m *Matcher) excludedByAny(name string, detected []models.DetectedTechnology) bool {
+	for _, tech := range detected {
+		if m.excludes(name, tech.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// RuleSet holds misconfiguration rules compiled once and evaluated against
+// the records of every domain analyzed
+type RuleSet struct {
+	rules []compiledRule
+}
+
+// compiledRule is a rule with its condition compiled
+type compiledRule struct {
+	rule      models.Rule
+	condition *compiledCondition
+}
+
+// NewRuleSet compiles every rule. Rules are written like signature
+// conditions; match conditions without record types apply to every record.
+// Invalid rules are left out and reported together in the returned error;
+// the rule set is still usable with the remaining rules.
+func NewRuleSet(rules models.RuleFile) (*RuleSet, error) {
+	ruleSet := &RuleSet{}
+	seen := make(map[string]bool)
+
+	var invalid []string
+	for _, rule := range rules.Rules {
+		switch {
+		case rule.ID == "":
+			invalid = append(invalid, fmt.Sprintf("rule %q: missing id", rule.Description))
+			continue
+		case seen[rule.ID]:
+			invalid = append(invalid, fmt.Sprintf("rule %s: duplicate id", rule.ID))
+			continue
+		case !models.ValidSeverity(rule.Severity):
+			invalid = append(invalid, fmt.Sprintf("rule %s: unknown severity %q", rule.ID, rule.Severity))
+			continue
+		case rule.Description == "":
+			invalid = append(invalid, fmt.Sprintf("rule %s: missing description", rule.ID))
+			continue
+		}
+		seen[rule.ID] = true
+
+		condition, err := compileCondition(rule.Condition, []string{"*"})
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("rule %s: %v", rule.ID, err))
+			continue
+		}
+		ruleSet.rules = append(ruleSet.rules, compiledRule{rule: rule, condition: condition})
+	}
+
+	if len(invalid) > 0 {
+		return ruleSet, fmt.Errorf("invalid rules:\n  %s", strings.Join(invalid, "\n  "))
+	}
+	return ruleSet, nil
+}
+
+// Len returns the number of rules in the set
+func (r *RuleSet) Len() int {
+	return len(r.rules)
+}
+
+// Evaluate returns a finding for every rule whose condition holds, in rule
+// order. The records that made a condition hold become the evidence; rules
+// about something missing have none.
+func (r *RuleSet) Evaluate(records []models.DNSResponse) []models.Finding {
+	var findings []models.Finding
+	for _, compiled := range r.rules {
+		holds, matches := compiled.condition.evaluate(records)
+		if !holds {
+			continue
+		}
+
+		finding := models.Finding{
+			ID:          compiled.rule.ID,
+			Severity:    compiled.rule.Severity,
+			Description: compiled.rule.Description,
+			Remediation: compiled.rule.Remediation,
+		}
+		for _, match := range matches {
+			evidence := fmt.Sprintf("%s %s %s", match.record.Owner(), match.record.RecordType, match.record.Value)
+			if !containsString(finding.Evidence, evidence) {
+				finding.Evidence = append(finding.Evidence, evidence)
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
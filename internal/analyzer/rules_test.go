@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+func TestRules(t *testing.T) {
+	data, err := os.ReadFile("../../data/rules.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var file models.RuleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules, err := NewRuleSet(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A signed zone with CAA records and nothing wrong with it
+	clean := []models.DNSResponse{
+		{Domain: "example.com.", RecordType: "SOA", Value: "ns1.example.com. hostmaster.example.com. 1 7200 900 1209600 300"},
+		{Domain: "example.com.", RecordType: "TXT", Value: "v=spf1 include:_spf.google.com -all"},
+		{Domain: "_dmarc.example.com.", RecordType: "TXT", Value: "v=DMARC1; p=reject; sp=none"},
+		{Domain: "example.com.", RecordType: "CAA", Value: `0 issue "letsencrypt.org"`},
+		{Domain: "example.com.", RecordType: "DNSKEY", Value: "257 3 13 abc"},
+		{Domain: "example.com.", RecordType: "A", Value: "192.0.2.10"},
+		{Domain: "example.com.", RecordType: "MX", Value: "10 mx.example.com."},
+	}
+
+	testCases := []struct {
+		name     string
+		records  []models.DNSResponse
+		expected []string
+		evidence string
+	}{
+		{"clean zone", nil, nil, ""},
+		{"SPF +all", []models.DNSResponse{{Domain: "example.com.", RecordType: "TXT", Value: "v=spf1 a mx +all"}}, []string{"spf-pass-all"}, "example.com. TXT v=spf1 a mx +all"},
+		{"SPF bare all", []models.DNSResponse{{Domain: "example.com.", RecordType: "TXT", Value: "v=spf1 all"}}, []string{"spf-pass-all"}, ""},
+		{"DMARC p=none", []models.DNSResponse{{Domain: "_dmarc.example.com.", RecordType: "TXT", Value: "v=DMARC1;p=none; rua=mailto:d@example.com"}}, []string{"dmarc-policy-none"}, ""},
+		{"private IPv4 address", []models.DNSResponse{{Domain: "vpn.example.com.", RecordType: "A", Value: "10.1.2.3"}}, []string{"private-address"}, "vpn.example.com. A 10.1.2.3"},
+		{"HINFO and RP", []models.DNSResponse{
+			{Domain: "example.com.", RecordType: "HINFO", Value: "\"PC\" \"Linux\""},
+			{Domain: "example.com.", RecordType: "RP", Value: "admin.example.com. ."},
+		}, []string{"hinfo-disclosure", "rp-disclosure"}, ""},
+		{"wildcard MX", []models.DNSResponse{{Domain: "*.example.com.", Name: "*.example.com.", RecordType: "MX", Value: "10 mx.example.com."}}, []string{"wildcard-mx"}, "*.example.com. MX 10 mx.example.com."},
+		{"short SOA expire", []models.DNSResponse{{Domain: "example.com.", RecordType: "SOA", Value: "ns1.example.com. hostmaster.example.com. 2 7200 900 86400 300"}}, []string{"soa-expire-low"}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := rules.Evaluate(append(append([]models.DNSResponse(nil), clean...), tc.records...))
+
+			var ids []string
+			for _, finding := range findings {
+				ids = append(ids, finding.ID)
+				if finding.Severity == "" || finding.Description == "" || finding.Remediation == "" {
+					t.Errorf("Finding %s is incomplete: %+v", finding.ID, finding)
+				}
+			}
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, ids)
+			}
+			if tc.evidence != "" && !containsString(findings[0].Evidence, tc.evidence) {
+				t.Errorf("Expected evidence %q, got %v", tc.evidence, findings[0].Evidence)
+			}
+		})
+	}
+
+	_, err = NewRuleSet(models.RuleFile{Rules: []models.Rule{
+		{ID: "no-severity", Description: "x", Condition: models.Condition{Match: &models.Pattern{Regex: "."}}},
+		{ID: "bad-regex", Severity: models.SeverityLow, Description: "x", Condition: models.Condition{Match: &models.Pattern{Regex: "("}}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "no-severity") || !strings.Contains(err.Error(), "bad-regex") {
+		t.Errorf("Expected both invalid rules to be reported, got %v", err)
+	}
+}
+
+// wildcardResolver answers MX queries for every name under example.com
+type wildcardResolver struct{}
+
+func (wildcardResolver) Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error) {
+	if recordType != "MX" || !strings.HasSuffix(name, ".example.com.") {
+		return nil, nil
+	}
+	return []models.DNSResponse{
+		{Domain: name, Name: name, RecordType: "MX", TTL: 300, Value: "10 catchall.example.net."},
+		{Domain: name, Name: "catchall.example.net.", RecordType: "MX", TTL: 300, Value: "10 other.example.net."},
+	}, nil
+}
+
+func TestWildcardRecords(t *testing.T) {
+	records := wildcardRecords(context.Background(), wildcardResolver{}, "example.com.")
+	if len(records) != 1 {
+		t.Fatalf("Expected one wildcard record, got %v", records)
+	}
+	if records[0].Owner() != "*.example.com." || records[0].Value != "10 catchall.example.net." {
+		t.Errorf("Unexpected wildcard record %+v", records[0])
+	}
+
+	if records := wildcardRecords(context.Background(), fakeResolver{}, "example.com."); len(records) != 0 {
+		t.Errorf("Expected no wildcard records, got %v", records)
+	}
+}
+
+func TestWildcardProbeStaysOutOfDetection(t *testing.T) {
+	matcher, err := NewMatcher(models.SignatureFile{Signatures: []models.Signature{
+		{Name: "Catch-all Mail", RecordTypes: []string{"MX"}, Patterns: []models.Pattern{{Suffix: "catchall.example.net."}}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules, err := NewRuleSet(models.RuleFile{Rules: []models.Rule{
+		{ID: "wildcard-mx", Severity: models.SeverityMedium, Description: "wildcard MX", Condition: models.Condition{
+			Match: &models.Pattern{Prefix: "*.", Field: models.FieldName}, RecordTypes: []string{"MX"},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	config := Config{Rules: rules, IncludeRecords: true}
+	result := analyzeRecords(context.Background(), config, matcher, "example.com", wildcardResolver{}, nil)
+
+	if len(result.Findings) == 0 || result.Findings[0].ID != "wildcard-mx" {
+		t.Errorf("Expected the wildcard MX finding, got %v", result.Findings)
+	}
+	if len(result.DetectedTechnologies) != 0 {
+		t.Errorf("Expected the probe answers to stay out of detection, got %v", result.DetectedTechnologies)
+	}
+	for _, record := range result.AllRecords {
+		if strings.HasPrefix(record.Owner(), "*.") {
+			t.Errorf("Expected the probe answers to stay out of allRecords, got %+v", record)
+		}
+	}
+}
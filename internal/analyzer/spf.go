@@ -0,0 +1,495 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+const (
+	// spfMaxLookups is the RFC 7208 limit on DNS-querying mechanisms and modifiers
+	spfMaxLookups = 10
+	// spfMaxVoidLookups is the RFC 7208 limit on lookups returning no records
+	spfMaxVoidLookups = 2
+	// spfMaxMXHosts is the RFC 7208 limit on address lookups per mx mechanism
+	spfMaxMXHosts = 10
+	// spfMaxDepth stops runaway include chains that never hit the lookup limit
+	spfMaxDepth = 20
+)
+
+// SPF evaluation statuses
+const (
+	SPFStatusNone      = "none"
+	SPFStatusValid     = "valid"
+	SPFStatusPermError = "permerror"
+	SPFStatusTempError = "temperror"
+)
+
+// spfProviders maps include domains to the sending provider they belong to.
+// A domain matches when it equals the key or is a subdomain of it.
+var spfProviders = map[string]string{
+	"spf.protection.outlook.com": "Microsoft 365",
+	"_spf.google.com":            "Google Workspace",
+	"_spf.salesforce.com":        "Salesforce",
+	"sendgrid.net":               "SendGrid",
+	"mailgun.org":                "Mailgun",
+	"servers.mcsv.net":           "Mailchimp",
+	"spf.mandrillapp.com":        "Mandrill",
+	"amazonses.com":              "Amazon SES",
+	"spf.mtasv.net":              "Postmark",
+	"_spf.createsend.com":        "Campaign Monitor",
+	"spf.mailjet.com":            "Mailjet",
+	"spf.brevo.com":              "Brevo",
+	"spf.sendinblue.com":         "Brevo",
+	"hubspotemail.net":           "HubSpot",
+	"mktomail.com":               "Marketo",
+	"_spf.intacct.com":           "Sage Intacct",
+	"zendesk.com":                "Zendesk",
+	"_spf.freshdesk.com":         "Freshdesk",
+	"_spf.atlassian.net":         "Atlassian",
+	"spf.zoho.com":               "Zoho Mail",
+	"zoho.eu":                    "Zoho Mail",
+	"pphosted.com":               "Proofpoint",
+	"ppe-hosted.com":             "Proofpoint Essentials",
+	"mimecast.com":               "Mimecast",
+	"mim.ec":                     "Mimecast",
+	"messagelabs.com":            "Symantec Email Security",
+	"iphmx.com":                  "Cisco Secure Email",
+	"barracudanetworks.com":      "Barracuda Email Security",
+	"sophosxl.net":               "Sophos Email",
+	"_spf.qualtrics.com":         "Qualtrics",
+	"docusign.net":               "DocuSign",
+	"_spf.docusign.com":          "DocuSign",
+	"spf.messagingengine.com":    "Fastmail",
+	"spf.improvmx.com":           "ImprovMX",
+	"_spf.mlsend.com":            "MailerLite",
+	"spf.mailerlite.com":         "MailerLite",
+	"_spf.psm.knowbe4.com":       "KnowBe4",
+	"spf.smtp2go.com":            "SMTP2GO",
+	"sparkpostmail.com":          "SparkPost",
+	"spf.constantcontact.com":    "Constant Contact",
+	"_spf.elasticemail.com":      "Elastic Email",
+	"agari.com":                  "Agari",
+	"valimail.com":               "Valimail",
+}
+
+// spfEvaluator walks an SPF policy and its include tree, keeping the counters
+// shared across the whole evaluation
+type spfEvaluator struct {
+	ctx         context.Context
+	resolver    Resolver
+	result      *models.SPFResult
+	authorized  map[string]bool
+	providers   map[string]bool
+	visited     map[string]bool
+	lookups     int
+	voidLookups int
+}
+
+// AnalyzeSPF fetches and evaluates the SPF policy of a domain, expanding
+// include and redirect chains and counting lookups against the RFC 7208 limits
+func AnalyzeSPF(ctx context.Context, resolver Resolver, domain string) *models.SPFResult {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+
+	e := &spfEvaluator{
+		ctx:        ctx,
+		resolver:   resolver,
+		result:     &models.SPFResult{Status: SPFStatusValid},
+		authorized: make(map[string]bool),
+		providers:  make(map[string]bool),
+		visited:    make(map[string]bool),
+	}
+
+	record, err := e.fetchRecord(domain)
+	if err != nil {
+		e.result.Status = SPFStatusTempError
+		e.result.Errors = append(e.result.Errors, err.Error())
+		return e.result
+	}
+	if record == "" {
+		e.result.Status = SPFStatusNone
+		return e.result
+	}
+
+	e.result.Record = record
+	e.visited[domain] = true
+	e.result.Includes = e.evaluate(domain, record, 0, true)
+
+	e.result.DNSLookups = e.lookups
+	e.result.VoidLookups = e.voidLookups
+	if e.lookups > spfMaxLookups {
+		e.permError(fmt.Sprintf("too many DNS lookups: %d (limit %d)", e.lookups, spfMaxLookups))
+	}
+	if e.voidLookups > spfMaxVoidLookups {
+		e.permError(fmt.Sprintf("too many void lookups: %d (limit %d)", e.voidLookups, spfMaxVoidLookups))
+	}
+
+	for ip := range e.authorized {
+		e.result.AuthorizedIPs = append(e.result.AuthorizedIPs, ip)
+	}
+	sort.Strings(e.result.AuthorizedIPs)
+
+	for provider := range e.providers {
+		e.result.Providers = append(e.result.Providers, provider)
+	}
+	sort.Strings(e.result.Providers)
+
+	return e.result
+}
+
+// fetchRecord returns the single SPF record published at a domain, or an
+// empty string if there is none
+func (e *spfEvaluator) fetchRecord(domain string) (string, error) {
+	records, err := e.resolver.Lookup(e.ctx, domain, "TXT")
+	if err != nil {
+		return "", fmt.Errorf("error looking up SPF record for %s: %v", domain, err)
+	}
+
+	var spfRecords []string
+	for _, record := range records {
+		if record.RecordType != "TXT" {
+			continue
+		}
+		if isSPFRecord(record.Value) {
+			spfRecords = append(spfRecords, record.Value)
+		}
+	}
+
+	if len(spfRecords) > 1 {
+		e.permError(fmt.Sprintf("multiple SPF records published at %s", domain))
+	}
+	if len(spfRecords) == 0 {
+		return "", nil
+	}
+	return spfRecords[0], nil
+}
+
+// evaluate processes the terms of one SPF record. top is true for the record
+// published at the analyzed domain itself (or reached from it by redirect).
+func (e *spfEvaluator) evaluate(domain, record string, depth int, top bool) []models.SPFInclude {
+	var includes []models.SPFInclude
+	var redirect string
+	hasAll := false
+
+	if depth > spfMaxDepth {
+		e.permError(fmt.Sprintf("include chain too deep at %s", domain))
+		return nil
+	}
+
+	for _, term := range strings.Fields(record)[1:] {
+		if name, value, ok := splitSPFModifier(term); ok {
+			switch name {
+			case "redirect":
+				if redirect != "" {
+					e.permError(fmt.Sprintf("duplicate redirect modifier in SPF record for %s", domain))
+				}
+				redirect = value
+			case "exp":
+				// Explanations are only fetched on failure and don't count as lookups
+			}
+			continue
+		}
+
+		qualifier := "+"
+		if strings.ContainsAny(term[:1], "+-~?") {
+			qualifier = term[:1]
+			term = term[1:]
+		}
+
+		mechanism, argument := term, ""
+		if i := strings.IndexAny(term, ":/"); i >= 0 {
+			mechanism, argument = term[:i], term[i:]
+		}
+		mechanism = strings.ToLower(mechanism)
+		argument = strings.TrimPrefix(argument, ":")
+
+		switch mechanism {
+		case "all":
+			hasAll = true
+			if top {
+				e.result.AllQualifier = qualifier
+				switch qualifier {
+				case "+":
+					e.result.Warnings = append(e.result.Warnings, "+all authorizes every host on the internet to send mail for this domain")
+				case "?":
+					e.result.Warnings = append(e.result.Warnings, "?all gives a neutral result for unauthorized senders and offers no protection")
+				}
+			}
+
+		case "ip4", "ip6":
+			cidr, err := normalizeSPFNetwork(mechanism, argument)
+			if err != nil {
+				e.permError(fmt.Sprintf("invalid %s mechanism in SPF record for %s: %v", mechanism, domain, err))
+				continue
+			}
+			if qualifier == "+" {
+				e.authorized[cidr] = true
+			}
+
+		case "a", "mx":
+			target, cidr4, cidr6 := splitSPFDualCIDR(argument)
+			e.lookups++
+			target, ok := e.expandDomain(target, domain, mechanism)
+			if !ok {
+				continue
+			}
+			var hosts []string
+			if mechanism == "mx" {
+				hosts = e.resolveMX(target)
+			} else {
+				hosts = []string{target}
+			}
+			found := false
+			for _, host := range hosts {
+				for _, ip := range e.resolveAddresses(host) {
+					found = true
+					if qualifier == "+" {
+						e.authorized[applySPFPrefix(ip, cidr4, cidr6)] = true
+					}
+				}
+			}
+			if mechanism == "a" && !found {
+				e.voidLookups++
+			}
+
+		case "ptr":
+			e.lookups++
+			e.result.Warnings = appendUnique(e.result.Warnings, "ptr mechanism is deprecated by RFC 7208 and should not be used")
+
+		case "exists":
+			e.lookups++
+			target, ok := e.expandDomain(argument, domain, mechanism)
+			if !ok {
+				continue
+			}
+			if len(e.resolveAddresses(target)) == 0 {
+				e.voidLookups++
+			}
+
+		case "include":
+			e.lookups++
+			target, ok := e.expandDomain(argument, domain, mechanism)
+			if !ok {
+				continue
+			}
+			includes = append(includes, e.follow("include", target, depth, false))
+
+		default:
+			e.permError(fmt.Sprintf("unknown mechanism %q in SPF record for %s", mechanism, domain))
+		}
+	}
+
+	// redirect is ignored when the record contains an all mechanism
+	if redirect != "" && !hasAll {
+		e.lookups++
+		if target, ok := e.expandDomain(redirect, domain, "redirect"); ok {
+			includes = append(includes, e.follow("redirect", target, depth, top))
+		}
+	}
+
+	return includes
+}
+
+// follow evaluates the record an include or redirect points at and returns its
+// node in the include tree. A redirect target replaces the current record, so
+// its all mechanism counts as the domain's own when reached from the top.
+func (e *spfEvaluator) follow(mechanism, target string, depth int, top bool) models.SPFInclude {
+	node := models.SPFInclude{Mechanism: mechanism, Domain: target}
+	e.recordProvider(target)
+
+	if e.visited[target] {
+		e.permError(fmt.Sprintf("SPF %s loop detected at %s", mechanism, target))
+		return node
+	}
+
+	record, err := e.fetchRecord(target)
+	if err != nil {
+		e.tempError(err.Error())
+		return node
+	}
+	if record == "" {
+		e.permError(fmt.Sprintf("%s target %s has no SPF record", mechanism, target))
+		return node
+	}
+
+	e.visited[target] = true
+	node.Record = record
+	node.Includes = e.evaluate(target, record, depth+1, top)
+	delete(e.visited, target)
+	return node
+}
+
+// resolveMX returns the exchange hosts for a domain, enforcing the per-mechanism host limit
+func (e *spfEvaluator) resolveMX(domain string) []string {
+	records, err := e.resolver.Lookup(e.ctx, domain, "MX")
+	if err != nil {
+		e.tempError(fmt.Sprintf("error looking up MX records for %s: %v", domain, err))
+		return nil
+	}
+
+	var hosts []string
+	for _, record := range records {
+		if record.RecordType != "MX" {
+			continue
+		}
+		fields := strings.Fields(record.Value)
+		if len(fields) != 2 {
+			continue
+		}
+		hosts = append(hosts, strings.TrimSuffix(fields[1], "."))
+	}
+
+	if len(hosts) == 0 {
+		e.voidLookups++
+	}
+	if len(hosts) > spfMaxMXHosts {
+		e.permError(fmt.Sprintf("mx mechanism for %s returns %d hosts (limit %d)", domain, len(hosts), spfMaxMXHosts))
+		hosts = hosts[:spfMaxMXHosts]
+	}
+	return hosts
+}
+
+// resolveAddresses returns the IPv4 and IPv6 addresses of a host
+func (e *spfEvaluator) resolveAddresses(host string) []string {
+	var addresses []string
+	for _, recordType := range []string{"A", "AAAA"} {
+		records, err := e.resolver.Lookup(e.ctx, host, recordType)
+		if err != nil {
+			e.tempError(fmt.Sprintf("error looking up %s records for %s: %v", recordType, host, err))
+			continue
+		}
+		for _, record := range records {
+			if record.RecordType == recordType {
+				addresses = append(addresses, record.Value)
+			}
+		}
+	}
+	return addresses
+}
+
+// expandDomain resolves the target of a mechanism. Macros that depend on the
+// connecting client cannot be expanded statically; those mechanisms still
+// count towards the lookup limit but are not followed.
+func (e *spfEvaluator) expandDomain(spec, current, mechanism string) (string, bool) {
+	if spec == "" {
+		return current, true
+	}
+	if !strings.Contains(spec, "%") {
+		return strings.TrimSuffix(strings.ToLower(spec), "."), true
+	}
+
+	replacer := strings.NewReplacer("%{d}", current, "%{o}", current, "%%", "%", "%_", " ", "%-", "%20")
+	expanded := replacer.Replace(spec)
+	if strings.Contains(expanded, "%") {
+		e.result.Warnings = appendUnique(e.result.Warnings,
+			fmt.Sprintf("%s:%s uses sender-dependent macros and was not expanded", mechanism, spec))
+		return "", false
+	}
+	return strings.TrimSuffix(strings.ToLower(expanded), "."), true
+}
+
+// recordProvider notes the sending provider behind an include domain, if known
+func (e *spfEvaluator) recordProvider(domain string) {
+	for suffix, provider := range spfProviders {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			e.providers[provider] = true
+		}
+	}
+}
+
+// permError records a permanent error, which overrides any other status
+func (e *spfEvaluator) permError(message string) {
+	e.result.Status = SPFStatusPermError
+	e.result.Errors = appendUnique(e.result.Errors, message)
+}
+
+// tempError records a transient lookup failure unless a permanent error was already found
+func (e *spfEvaluator) tempError(message string) {
+	if e.result.Status != SPFStatusPermError {
+		e.result.Status = SPFStatusTempError
+	}
+	e.result.Errors = appendUnique(e.result.Errors, message)
+}
+
+// isSPFRecord checks whether a TXT value is an SPF version 1 record
+func isSPFRecord(value string) bool {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	return lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ")
+}
+
+// splitSPFModifier splits a name=value modifier term. Mechanisms return false.
+func splitSPFModifier(term string) (string, string, bool) {
+	i := strings.IndexAny(term, "=:/")
+	if i <= 0 || term[i] != '=' {
+		return "", "", false
+	}
+	return strings.ToLower(term[:i]), term[i+1:], true
+}
+
+// splitSPFDualCIDR separates the domain-spec of an a or mx mechanism from its
+// optional IPv4 and IPv6 prefix lengths
+func splitSPFDualCIDR(argument string) (string, string, string) {
+	target, cidr4, cidr6 := argument, "", ""
+	if i := strings.Index(target, "//"); i >= 0 {
+		cidr6 = target[i+2:]
+		target = target[:i]
+	}
+	if i := strings.Index(target, "/"); i >= 0 {
+		cidr4 = target[i+1:]
+		target = target[:i]
+	}
+	return target, cidr4, cidr6
+}
+
+// applySPFPrefix turns an address into a network using the mechanism's prefix lengths
+func applySPFPrefix(ip, cidr4, cidr6 string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+
+	bits, prefix := 32, cidr4
+	if parsed.To4() == nil {
+		bits, prefix = 128, cidr6
+	}
+	length, err := strconv.Atoi(prefix)
+	if err != nil || length < 0 || length > bits {
+		length = bits
+	}
+
+	network := &net.IPNet{IP: parsed.Mask(net.CIDRMask(length, bits)), Mask: net.CIDRMask(length, bits)}
+	return network.String()
+}
+
+// normalizeSPFNetwork validates an ip4 or ip6 argument and returns it in CIDR notation
+func normalizeSPFNetwork(mechanism, argument string) (string, error) {
+	if !strings.Contains(argument, "/") {
+		if mechanism == "ip4" {
+			argument += "/32"
+		} else {
+			argument += "/128"
+		}
+	}
+
+	ip, network, err := net.ParseCIDR(argument)
+	if err != nil {
+		return "", err
+	}
+	if (mechanism == "ip4") != (ip.To4() != nil) {
+		return "", fmt.Errorf("%s is not an %s address", ip, mechanism)
+	}
+	return network.String(), nil
+}
+
+// appendUnique appends a string to a slice unless it is already present
+func appendUnique(slice []string, item string) []string {
+	if containsString(slice, item) {
+		return slice
+	}
+	return append(slice, item)
+}
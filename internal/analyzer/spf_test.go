@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// fakeResolver answers lookups from a static "name TYPE" -> values table
+type fakeResolver map[string][]string
+
+func (f fakeResolver) Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error) {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	var responses []models.DNSResponse
+	for _, value := range f[name+" "+recordType] {
+		responses = append(responses, models.DNSResponse{
+			Domain:     name + ".",
+			RecordType: recordType,
+			TTL:        300,
+			Value:      value,
+		})
+	}
+	return responses, nil
+}
+
+func TestAnalyzeSPF(t *testing.T) {
+	testCases := []struct {
+		name          string
+		records       fakeResolver
+		status        string
+		allQualifier  string
+		lookups       int
+		authorizedIPs []string
+		providers     []string
+		errorContains string
+	}{
+		{
+			name:    "No SPF record",
+			records: fakeResolver{"example.com TXT": {"google-site-verification=abc"}},
+			status:  SPFStatusNone,
+		},
+		{
+			name: "Include chain is flattened",
+			records: fakeResolver{
+				"example.com TXT":                {"v=spf1 ip4:192.0.2.10 include:_spf.example.net -all"},
+				"_spf.example.net TXT":           {"v=spf1 ip4:198.51.100.0/24 include:spf.protection.outlook.com ~all"},
+				"spf.protection.outlook.com TXT": {"v=spf1 ip6:2001:db8::/32 -all"},
+			},
+			status:        SPFStatusValid,
+			allQualifier:  "-",
+			lookups:       2,
+			authorizedIPs: []string{"192.0.2.10/32", "198.51.100.0/24", "2001:db8::/32"},
+			providers:     []string{"Microsoft 365"},
+		},
+		{
+			name: "A and MX mechanisms are resolved",
+			records: fakeResolver{
+				"example.com TXT":      {"v=spf1 a mx/28 ?all"},
+				"example.com A":        {"192.0.2.1"},
+				"example.com MX":       {"10 mail.example.com."},
+				"mail.example.com A":   {"192.0.2.35"},
+				"mail.example.com TXT": {},
+			},
+			status:        SPFStatusValid,
+			allQualifier:  "?",
+			lookups:       2,
+			authorizedIPs: []string{"192.0.2.1/32", "192.0.2.32/28"},
+		},
+		{
+			name: "Redirect supplies the all mechanism",
+			records: fakeResolver{
+				"example.com TXT":      {"v=spf1 redirect=_spf.example.com"},
+				"_spf.example.com TXT": {"v=spf1 ip4:192.0.2.0/24 +all"},
+			},
+			status:        SPFStatusValid,
+			allQualifier:  "+",
+			lookups:       1,
+			authorizedIPs: []string{"192.0.2.0/24"},
+		},
+		{
+			name: "Too many lookups",
+			records: fakeResolver{
+				"example.com TXT": {"v=spf1 include:a.test include:b.test include:c.test include:d.test include:e.test include:f.test -all"},
+				"a.test TXT":      {"v=spf1 include:g.test include:h.test -all"},
+				"b.test TXT":      {"v=spf1 include:i.test -all"},
+				"c.test TXT":      {"v=spf1 -all"},
+				"d.test TXT":      {"v=spf1 -all"},
+				"e.test TXT":      {"v=spf1 -all"},
+				"f.test TXT":      {"v=spf1 -all"},
+				"g.test TXT":      {"v=spf1 -all"},
+				"h.test TXT":      {"v=spf1 -all"},
+				"i.test TXT":      {"v=spf1 include:j.test -all"},
+				"j.test TXT":      {"v=spf1 include:k.test -all"},
+				"k.test TXT":      {"v=spf1 -all"},
+			},
+			status:        SPFStatusPermError,
+			allQualifier:  "-",
+			lookups:       11,
+			errorContains: "too many DNS lookups",
+		},
+		{
+			name: "Too many void lookups",
+			records: fakeResolver{
+				"example.com TXT": {"v=spf1 a:gone1.example.com a:gone2.example.com exists:gone3.example.com -all"},
+			},
+			status:        SPFStatusPermError,
+			allQualifier:  "-",
+			lookups:       3,
+			errorContains: "too many void lookups",
+		},
+		{
+			name: "Include without SPF record",
+			records: fakeResolver{
+				"example.com TXT": {"v=spf1 include:missing.example.net -all"},
+			},
+			status:        SPFStatusPermError,
+			allQualifier:  "-",
+			lookups:       1,
+			errorContains: "has no SPF record",
+		},
+		{
+			name: "Multiple SPF records",
+			records: fakeResolver{
+				"example.com TXT": {"v=spf1 -all", "v=spf1 ip4:192.0.2.1 -all"},
+			},
+			status:        SPFStatusPermError,
+			allQualifier:  "-",
+			errorContains: "multiple SPF records",
+		},
+		{
+			name: "Sender macros are counted but not followed",
+			records: fakeResolver{
+				"example.com TXT": {"v=spf1 exists:%{i}._i.%{d}._d.espf.agari.com -all"},
+			},
+			status:       SPFStatusValid,
+			allQualifier: "-",
+			lookups:      1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := AnalyzeSPF(context.Background(), tc.records, "example.com.")
+
+			if result.Status != tc.status {
+				t.Errorf("Expected status %s, got %s (errors: %v)", tc.status, result.Status, result.Errors)
+			}
+			if result.AllQualifier != tc.allQualifier {
+				t.Errorf("Expected all qualifier %q, got %q", tc.allQualifier, result.AllQualifier)
+			}
+			if result.DNSLookups != tc.lookups {
+				t.Errorf("Expected %d lookups, got %d", tc.lookups, result.DNSLookups)
+			}
+			if tc.authorizedIPs != nil && !reflect.DeepEqual(result.AuthorizedIPs, tc.authorizedIPs) {
+				t.Errorf("Expected authorized IPs %v, got %v", tc.authorizedIPs, result.AuthorizedIPs)
+			}
+			if !reflect.DeepEqual(result.Providers, tc.providers) {
+				t.Errorf("Expected providers %v, got %v", tc.providers, result.Providers)
+			}
+			if tc.errorContains != "" && !strings.Contains(strings.Join(result.Errors, "\n"), tc.errorContains) {
+				t.Errorf("Expected an error containing %q, got %v", tc.errorContains, result.Errors)
+			}
+		})
+	}
+}
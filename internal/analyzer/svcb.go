@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Elite-Security-Systems/radar/internal/dns"
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// AnalyzeServiceBindings parses the SvcParams of every SVCB and HTTPS record in
+// a record set, e.g. to show ALPN protocols, address hints and ECH configs
+func AnalyzeServiceBindings(records []models.DNSResponse, debug bool) []models.ServiceBinding {
+	var bindings []models.ServiceBinding
+	for _, record := range records {
+		if record.RecordType != "SVCB" && record.RecordType != "HTTPS" {
+			continue
+		}
+
+		binding, err := dns.ParseServiceBinding(record)
+		if err != nil {
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] %v\n", err)
+			}
+			continue
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/dns"
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+func TestAnalyzeServiceBindings(t *testing.T) {
+	zone := `$ORIGIN example.com.
+@ 300 IN A 192.0.2.10
+@ 300 IN HTTPS 1 . alpn="h3,h2" ipv4hint=192.0.2.10 ech=AEX+DQA=
+_dns 300 IN SVCB 1 dns.example.com. alpn=dot port=853 key65000="hello world"
+`
+	_, records, err := dns.ParseZone(strings.NewReader(zone), "example.com.", "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Records loaded from saved results only have their value
+	records = append(records,
+		models.DNSResponse{Domain: "www.example.com.", RecordType: "HTTPS", TTL: 300, Value: "0 example.com."},
+		models.DNSResponse{Domain: "broken.example.com.", RecordType: "HTTPS", TTL: 300, Value: "not a binding"},
+	)
+
+	expected := []models.ServiceBinding{
+		{
+			Name:       "example.com.",
+			RecordType: "HTTPS",
+			Priority:   1,
+			Target:     ".",
+			ALPN:       []string{"h3", "h2"},
+			IPv4Hints:  []string{"192.0.2.10"},
+			ECHConfig:  "AEX+DQA=",
+			HTTP3:      true,
+			ECH:        true,
+		},
+		{
+			Name:        "_dns.example.com.",
+			RecordType:  "SVCB",
+			Priority:    1,
+			Target:      "dns.example.com.",
+			ALPN:        []string{"dot"},
+			Port:        853,
+			OtherParams: map[string]string{"key65000": "hello world"},
+		},
+		{
+			Name:       "www.example.com.",
+			RecordType: "HTTPS",
+			Target:     "example.com.",
+			AliasMode:  true,
+		},
+	}
+
+	bindings := AnalyzeServiceBindings(records, false)
+	if !reflect.DeepEqual(bindings, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, bindings)
+	}
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// wildcardProbeTypes are the record types looked up at a random name to
+// find wildcard records
+var wildcardProbeTypes = []string{"MX"}
+
+// wildcardRecords looks up a random name under the domain, which only
+// answers when the zone has wildcard records. Answers owned by the random
+// name are returned under the wildcard owner ("*.example.com.") so that
+// rules can tell them from records at the domain itself.
+func wildcardRecords(ctx context.Context, resolver Resolver, domain string) []models.DNSResponse {
+	probe := fmt.Sprintf("radar-%08x.%s", rand.Uint32(), domain)
+	wildcard := "*." + domain
+
+	var records []models.DNSResponse
+	for _, recordType := range wildcardProbeTypes {
+		responses, err := resolver.Lookup(ctx, probe, recordType)
+		if err != nil {
+			continue
+		}
+		for _, record := range responses {
+			// Skip the targets of a CNAME chain, which belong to other names
+			if !strings.EqualFold(strings.TrimSuffix(record.Owner(), "."), strings.TrimSuffix(probe, ".")) {
+				continue
+			}
+			record.Domain = wildcard
+			record.Name = wildcard
+			records = append(records, record)
+		}
+	}
+	return records
+}
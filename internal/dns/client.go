@@ -20,6 +20,7 @@ type Client struct {
 	recordCounter int
 	responsesMap  map[string]models.DNSResponse
 	mutex         sync.Mutex
+	iterative     *IterativeResolver
 }
 
 // NewClient creates a new DNS client
@@ -31,6 +32,16 @@ func NewClient(debug bool) *Client {
 	}
 }
 
+// NewClientWithResolvers creates a new DNS client that uses the given resolvers
+// instead of the default public ones
+func NewClientWithResolvers(debug bool, resolvers []string) *Client {
+	client := NewClient(debug)
+	if len(resolvers) > 0 {
+		client.resolvers = resolvers
+	}
+	return client
+}
+
 // getResolvers returns the list of DNS resolvers to use
 func getResolvers() []string {
 	return []string{
@@ -57,9 +68,11 @@ func (c *Client) QueryAllRecords(ctx context.Context, domain string, queryTimeou
 	queryCtx, queryCancel := context.WithTimeout(ctx, queryTimeout)
 	defer queryCancel()
 
-	// Query system resolver for TXT records
-	wg.Add(1)
-	go c.querySystemResolver(queryCtx, &wg, domain, maxRecords)
+	// Query system resolver for TXT records, unless resolving iteratively
+	if c.iterative == nil {
+		wg.Add(1)
+		go c.querySystemResolver(queryCtx, &wg, domain, maxRecords)
+	}
 
 	// Query miekg/dns resolvers in parallel
 	for _, resolver := range c.resolvers {
@@ -70,6 +83,10 @@ func (c *Client) QueryAllRecords(ctx context.Context, domain string, queryTimeou
 		// Secondary records
 		wg.Add(1)
 		go c.querySecondaryRecords(queryCtx, &wg, domain, resolver, maxRecords)
+
+		// Names under the domain that signatures match by owner name
+		wg.Add(1)
+		go c.queryOwnerNames(queryCtx, &wg, domain, resolver, maxRecords)
 	}
 
 	// Wait for all queries to complete or timeout
@@ -185,6 +202,7 @@ func (c *Client) querySystemResolver(ctx context.Context, wg *sync.WaitGroup, do
 			if _, exists := c.responsesMap[recordKey]; !exists {
 				c.responsesMap[recordKey] = models.DNSResponse{
 					Domain:     domain,
+					Name:       domain,
 					RecordType: "TXT",
 					TTL:        300, // Default TTL
 					Value:      result.txt,
@@ -258,7 +276,7 @@ func (c *Client) queryPriorityRecords(ctx context.Context, wg *sync.WaitGroup, d
 		msg.RecursionDesired = true
 
 		// Make the query
-		resp, _, err := client.Exchange(msg, resolver)
+		resp, err := c.exchange(ctx, client, msg, resolver)
 
 		if c.debug {
 			if err != nil {
@@ -293,10 +311,12 @@ func (c *Client) queryPriorityRecords(ctx context.Context, wg *sync.WaitGroup, d
 			// Only add if we haven't seen this exact record before
 			if _, exists := c.responsesMap[recordKey]; !exists {
 				c.responsesMap[recordKey] = models.DNSResponse{
-					Domain:     domain,
-					RecordType: typeName,
-					TTL:        rr.Header().Ttl,
-					Value:      value,
+					Domain:         domain,
+					Name:           strings.ToLower(rr.Header().Name),
+					RecordType:     typeName,
+					TTL:            rr.Header().Ttl,
+					Value:          value,
+					ServiceBinding: NewServiceBinding(rr),
 				}
 				c.recordCounter++
 
@@ -370,7 +390,7 @@ func (c *Client) querySecondaryRecords(ctx context.Context, wg *sync.WaitGroup,
 		msg.RecursionDesired = true
 
 		// Make the query
-		resp, _, err := client.Exchange(msg, resolver)
+		resp, err := c.exchange(ctx, client, msg, resolver)
 
 		if c.debug {
 			if err != nil {
@@ -404,10 +424,12 @@ func (c *Client) querySecondaryRecords(ctx context.Context, wg *sync.WaitGroup,
 			// Only add if we haven't seen this exact record before
 			if _, exists := c.responsesMap[recordKey]; !exists {
 				c.responsesMap[recordKey] = models.DNSResponse{
-					Domain:     domain,
-					RecordType: typeName,
-					TTL:        rr.Header().Ttl,
-					Value:      value,
+					Domain:         domain,
+					Name:           strings.ToLower(rr.Header().Name),
+					RecordType:     typeName,
+					TTL:            rr.Header().Ttl,
+					Value:          value,
+					ServiceBinding: NewServiceBinding(rr),
 				}
 				c.recordCounter++
 
@@ -420,6 +442,104 @@ func (c *Client) querySecondaryRecords(ctx context.Context, wg *sync.WaitGroup,
 	}
 }
 
+// ownerNames are names under the domain that signatures match by owner
+// name rather than by value. The other queries only ask about the domain
+// itself, so these would never be seen in a live scan.
+var ownerNames = []struct {
+	prefix string
+	types  []uint16
+}{
+	{"autodiscover.", []uint16{dns.TypeA, dns.TypeCNAME}},
+	{"_amazonses.", []uint16{dns.TypeTXT}},
+	{"_acme-challenge.", []uint16{dns.TypeTXT}},
+	{"_webauthn.", []uint16{dns.TypeTXT}},
+}
+
+// queryOwnerNames queries the ownerNames under the domain. Answers are kept
+// with their own record type, so the CNAME in front of an A answer is stored
+// as a CNAME.
+func (c *Client) queryOwnerNames(ctx context.Context, wg *sync.WaitGroup, domain string, resolver string, maxRecords int) {
+	defer wg.Done()
+
+	client := &dns.Client{
+		Timeout: 3 * time.Second,
+	}
+
+	for _, owner := range ownerNames {
+		name := owner.prefix + domain
+		for _, typeCode := range owner.types {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			msg := new(dns.Msg)
+			msg.SetQuestion(name, typeCode)
+			msg.RecursionDesired = true
+
+			resp, err := c.exchange(ctx, client, msg, resolver)
+			if c.debug && err != nil {
+				fmt.Printf("[DEBUG] Error querying %s %s records from %s: %v\n", name, RecordTypeToString(typeCode), resolver, err)
+			}
+			if err != nil || resp == nil || resp.Rcode != dns.RcodeSuccess {
+				continue
+			}
+
+			for _, rr := range resp.Answer {
+				value := ExtractValue(rr)
+				if value == "" {
+					continue
+				}
+				typeName := RecordTypeToString(rr.Header().Rrtype)
+				ownerName := strings.ToLower(rr.Header().Name)
+				recordKey := fmt.Sprintf("%s-%s-%s", ownerName, typeName, value)
+
+				c.mutex.Lock()
+				if c.recordCounter >= maxRecords {
+					c.mutex.Unlock()
+					return
+				}
+				if _, exists := c.responsesMap[recordKey]; !exists {
+					c.responsesMap[recordKey] = models.DNSResponse{
+						Domain:         name,
+						Name:           ownerName,
+						RecordType:     typeName,
+						TTL:            rr.Header().Ttl,
+						Value:          value,
+						ServiceBinding: NewServiceBinding(rr),
+					}
+					c.recordCounter++
+
+					if c.debug {
+						fmt.Printf("[DEBUG] Found %s record for %s via %s: %s\n", typeName, ownerName, resolver, value)
+					}
+				}
+				c.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// exchange sends a query to a resolver, or resolves it iteratively when the
+// client was created with NewIterativeClient
+func (c *Client) exchange(ctx context.Context, client *dns.Client, msg *dns.Msg, resolver string) (*dns.Msg, error) {
+	if c.iterative != nil {
+		return c.iterative.Exchange(ctx, msg)
+	}
+	resp, _, err := client.ExchangeContext(ctx, msg, resolver)
+	return resp, err
+}
+
+// Traces returns the referral steps of every query resolved iteratively, or
+// nil if the client uses recursive resolvers
+func (c *Client) Traces() []models.ResolutionTrace {
+	if c.iterative == nil {
+		return nil
+	}
+	return c.iterative.Traces()
+}
+
 // convertMapToSlice converts the internal map to a slice of DNSResponses
 func (c *Client) convertMapToSlice() []models.DNSResponse {
 	var allResponses []models.DNSResponse
@@ -433,3 +553,80 @@ func (c *Client) convertMapToSlice() []models.DNSResponse {
 	
 	return allResponses
 }
+
+// Lookup queries a single name and record type, trying each resolver in turn
+// until one of them answers. NXDOMAIN and empty answers both return no records
+// and no error so callers can tell a void lookup from a failed one.
+func (c *Client) Lookup(ctx context.Context, name string, recordType string) ([]models.DNSResponse, error) {
+	typeCode, ok := dns.StringToType[strings.ToUpper(recordType)]
+	if !ok {
+		return nil, fmt.Errorf("unknown record type: %s", recordType)
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), typeCode)
+	msg.RecursionDesired = true
+	msg.SetEdns0(4096, false)
+
+	client := &dns.Client{
+		Timeout: 3 * time.Second,
+	}
+
+	var lastErr error
+	for _, resolver := range c.resolvers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.exchange(ctx, client, msg, resolver)
+		if err == nil && resp != nil && resp.Truncated {
+			// Retry over TCP when the answer did not fit in a UDP datagram
+			tcpClient := &dns.Client{Net: "tcp", Timeout: client.Timeout}
+			resp, _, err = tcpClient.ExchangeContext(ctx, msg, resolver)
+		}
+
+		if c.debug {
+			if err != nil {
+				fmt.Printf("[DEBUG] Error looking up %s %s via %s: %v\n", name, recordType, resolver, err)
+			} else {
+				fmt.Printf("[DEBUG] Lookup %s %s via %s - Rcode: %d, Answer records: %d\n",
+					name, recordType, resolver, resp.Rcode, len(resp.Answer))
+			}
+		}
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.Rcode == dns.RcodeNameError {
+			return nil, nil
+		}
+		if resp.Rcode != dns.RcodeSuccess {
+			lastErr = fmt.Errorf("%s returned %s for %s %s", resolver, dns.RcodeToString[resp.Rcode], name, recordType)
+			continue
+		}
+
+		var responses []models.DNSResponse
+		for _, rr := range resp.Answer {
+			value := ExtractValue(rr)
+			if value == "" {
+				continue
+			}
+			responses = append(responses, models.DNSResponse{
+				Domain:         dns.Fqdn(name),
+				Name:           strings.ToLower(rr.Header().Name),
+				RecordType:     RecordTypeToString(rr.Header().Rrtype),
+				TTL:            rr.Header().Ttl,
+				Value:          value,
+				ServiceBinding: NewServiceBinding(rr),
+			})
+		}
+		return responses, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no resolvers configured")
+	}
+	return nil, lastErr
+}
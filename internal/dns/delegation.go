@@ -0,0 +1,80 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// QueryReferral asks a parent zone server (host:port) for the NS records of
+// zone without recursion and returns the delegated nameservers with any glue
+// addresses it sent along
+func (c *Client) QueryReferral(ctx context.Context, zone, server string) ([]string, map[string][]string, error) {
+	client := &dns.Client{Timeout: 2 * time.Second}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(zone), dns.TypeNS)
+	msg.RecursionDesired = false
+	msg.SetEdns0(1232, false)
+
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, nil, fmt.Errorf("server answered %s", rcodeName(resp.Rcode))
+	}
+
+	var nameservers []string
+	// The delegation is normally in the authority section; a parent that is
+	// also authoritative for the child answers directly
+	for _, rr := range append(resp.Ns, resp.Answer...) {
+		if ns, ok := rr.(*dns.NS); ok && strings.EqualFold(ns.Hdr.Name, dns.Fqdn(zone)) {
+			nameservers = append(nameservers, strings.ToLower(strings.TrimSuffix(ns.Ns, ".")))
+		}
+	}
+	if len(nameservers) == 0 {
+		return nil, nil, fmt.Errorf("no delegation for %s in response", zone)
+	}
+
+	glue := make(map[string][]string)
+	for _, rr := range resp.Extra {
+		host := strings.ToLower(strings.TrimSuffix(rr.Header().Name, "."))
+		switch rr := rr.(type) {
+		case *dns.A:
+			glue[host] = append(glue[host], rr.A.String())
+		case *dns.AAAA:
+			glue[host] = append(glue[host], rr.AAAA.String())
+		}
+	}
+
+	return nameservers, glue, nil
+}
+
+// QuerySOA asks a nameserver (host:port) for the SOA record of zone without
+// recursion and returns its serial and whether the answer was authoritative
+func (c *Client) QuerySOA(ctx context.Context, zone, server string) (uint32, bool, error) {
+	client := &dns.Client{Timeout: 2 * time.Second}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	msg.RecursionDesired = false
+
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err != nil {
+		return 0, false, err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return 0, false, fmt.Errorf("server answered %s", rcodeName(resp.Rcode))
+	}
+
+	for _, rr := range resp.Answer {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa.Serial, resp.Authoritative, nil
+		}
+	}
+	return 0, false, fmt.Errorf("no SOA record in response")
+}
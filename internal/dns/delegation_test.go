@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestQueryReferral(t *testing.T) {
+	server := startTestServer(t, func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		for _, record := range []string{
+			"example.com. 172800 IN NS ns1.example.com.",
+			"example.com. 172800 IN NS NS2.Example.NET.",
+		} {
+			rr, _ := dns.NewRR(record)
+			m.Ns = append(m.Ns, rr)
+		}
+		for _, record := range []string{
+			"ns1.example.com. 172800 IN A 192.0.2.1",
+			"ns1.example.com. 172800 IN AAAA 2001:db8::1",
+		} {
+			rr, _ := dns.NewRR(record)
+			m.Extra = append(m.Extra, rr)
+		}
+		w.WriteMsg(m)
+	})
+
+	client := NewClient(false)
+	nameservers, glue, err := client.QueryReferral(context.Background(), "example.com", server)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(nameservers, []string{"ns1.example.com", "ns2.example.net"}) {
+		t.Errorf("Unexpected nameservers %v", nameservers)
+	}
+	expectedGlue := map[string][]string{"ns1.example.com": {"192.0.2.1", "2001:db8::1"}}
+	if !reflect.DeepEqual(glue, expectedGlue) {
+		t.Errorf("Expected glue %v, got %v", expectedGlue, glue)
+	}
+}
+
+func TestQuerySOA(t *testing.T) {
+	server := startTestServer(t, func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Authoritative = true
+		rr, _ := dns.NewRR("example.com. 300 IN SOA ns1.example.com. hostmaster.example.com. 2024010101 7200 3600 1209600 300")
+		m.Answer = append(m.Answer, rr)
+		w.WriteMsg(m)
+	})
+
+	client := NewClient(false)
+	serial, authoritative, err := client.QuerySOA(context.Background(), "example.com", server)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if serial != 2024010101 || !authoritative {
+		t.Errorf("Expected authoritative serial 2024010101, got %d (authoritative %v)", serial, authoritative)
+	}
+}
@@ -0,0 +1,141 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/miekg/dns"
+)
+
+const (
+	// RecursionProbeName is the name nameservers are asked to recurse for.
+	// It must not be served by the zone being analyzed.
+	RecursionProbeName = "www.iana.org."
+
+	// amplificationHighFactor and amplificationMediumFactor are the
+	// response-to-request size ratios reported as high and medium severity
+	amplificationHighFactor   = 50
+	amplificationMediumFactor = 10
+)
+
+// ProbeExposure checks whether an authoritative server (host:port) recurses
+// for names outside its zones and measures how much larger than the query its
+// answers to ANY and DNSKEY queries for zone are
+func (c *Client) ProbeExposure(ctx context.Context, zone, server string) models.NameserverExposure {
+	exposure := models.NameserverExposure{Server: server}
+	client := &dns.Client{Timeout: 2 * time.Second}
+
+	// Ask for a name the server has no business answering
+	msg := new(dns.Msg)
+	msg.SetQuestion(RecursionProbeName, dns.TypeA)
+	msg.RecursionDesired = true
+
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err != nil {
+		exposure.Errors = append(exposure.Errors, fmt.Sprintf("recursion probe failed: %v", err))
+	} else if resp.RecursionAvailable && resp.Rcode == dns.RcodeSuccess && len(resp.Answer) > 0 {
+		exposure.OpenRecursion = true
+		evidence := []string{fmt.Sprintf("%s A answered with RA set", strings.TrimSuffix(RecursionProbeName, "."))}
+		for _, rr := range resp.Answer {
+			evidence = append(evidence, ExtractValue(rr))
+		}
+		exposure.Findings = append(exposure.Findings, models.Finding{
+			ID:          "ns-open-recursion",
+			Severity:    models.SeverityHigh,
+			Description: fmt.Sprintf("nameserver %s is an open resolver and recurses for names outside its zones", server),
+			Evidence:    evidence,
+			Remediation: "Disable recursion on authoritative servers or restrict it to internal clients",
+		})
+	} else if resp.RecursionAvailable {
+		exposure.Findings = append(exposure.Findings, models.Finding{
+			ID:          "ns-recursion-advertised",
+			Severity:    models.SeverityLow,
+			Description: fmt.Sprintf("nameserver %s sets the recursion available flag but did not recurse", server),
+			Evidence:    []string{fmt.Sprintf("%s A: %s with RA set", strings.TrimSuffix(RecursionProbeName, "."), rcodeName(resp.Rcode))},
+			Remediation: "Disable recursion on authoritative servers",
+		})
+	}
+
+	// Measure reflection amplification the way an attacker would: large
+	// EDNS buffer, DNSSEC OK set
+	for _, qtype := range []uint16{dns.TypeANY, dns.TypeDNSKEY} {
+		probe, err := measureAmplification(ctx, zone, qtype, server)
+		if err != nil {
+			exposure.Errors = append(exposure.Errors, fmt.Sprintf("%s amplification probe failed: %v", dns.TypeToString[qtype], err))
+			continue
+		}
+		exposure.Amplification = append(exposure.Amplification, probe)
+
+		severity := ""
+		switch {
+		case probe.Factor >= amplificationHighFactor:
+			severity = models.SeverityHigh
+		case probe.Factor >= amplificationMediumFactor:
+			severity = models.SeverityMedium
+		}
+		if severity != "" {
+			exposure.Findings = append(exposure.Findings, models.Finding{
+				ID:          "ns-amplification-" + strings.ToLower(probe.QueryType),
+				Severity:    severity,
+				Description: fmt.Sprintf("nameserver %s amplifies %s queries by a factor of %.1f", server, probe.QueryType, probe.Factor),
+				Evidence:    []string{fmt.Sprintf("%d byte %s query for %s returned %d bytes", probe.RequestSize, probe.QueryType, strings.TrimSuffix(dns.Fqdn(zone), "."), probe.ResponseSize)},
+				Remediation: "Answer ANY queries minimally (RFC 8482) and enable response rate limiting",
+			})
+		}
+	}
+
+	return exposure
+}
+
+// measureAmplification sends one query over UDP and compares the size of the
+// raw answer with the size of the query
+func measureAmplification(ctx context.Context, zone string, qtype uint16, server string) (models.AmplificationProbe, error) {
+	probe := models.AmplificationProbe{QueryType: dns.TypeToString[qtype]}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(zone), qtype)
+	msg.RecursionDesired = false
+	msg.SetEdns0(4096, true)
+
+	request, err := msg.Pack()
+	if err != nil {
+		return probe, fmt.Errorf("error packing query: %v", err)
+	}
+	probe.RequestSize = len(request)
+
+	dialer := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return probe, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err := conn.Write(request); err != nil {
+		return probe, err
+	}
+
+	buffer := make([]byte, dns.MaxMsgSize)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return probe, err
+		}
+
+		resp := new(dns.Msg)
+		if err := resp.Unpack(buffer[:n]); err != nil || resp.Id != msg.Id {
+			// Ignore stray or garbled datagrams
+			continue
+		}
+
+		probe.ResponseSize = n
+		probe.Truncated = resp.Truncated
+		probe.Factor = math.Round(float64(n)/float64(probe.RequestSize)*10) / 10
+		return probe, nil
+	}
+}
@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestProbeExposure(t *testing.T) {
+	testCases := []struct {
+		name          string
+		recurse       bool
+		txtRecords    int
+		openRecursion bool
+		findings      []string
+	}{
+		{
+			name: "Closed server with minimal answers",
+		},
+		{
+			name:          "Open resolver",
+			recurse:       true,
+			openRecursion: true,
+			findings:      []string{"ns-open-recursion"},
+		},
+		{
+			name:       "Large ANY answer",
+			txtRecords: 30,
+			findings:   []string{"ns-amplification-any"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := startTestServer(t, func(w dns.ResponseWriter, r *dns.Msg) {
+				m := new(dns.Msg)
+				m.SetReply(r)
+				q := r.Question[0]
+
+				switch {
+				case q.Name != "example.com.":
+					if tc.recurse {
+						m.RecursionAvailable = true
+						rr, _ := dns.NewRR(q.Name + " 300 IN A 192.0.2.1")
+						m.Answer = append(m.Answer, rr)
+					} else {
+						m.Rcode = dns.RcodeRefused
+					}
+				case q.Qtype == dns.TypeANY:
+					m.Authoritative = true
+					for i := 0; i < tc.txtRecords; i++ {
+						m.Answer = append(m.Answer, &dns.TXT{
+							Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+							Txt: []string{strings.Repeat("x", 200)},
+						})
+					}
+					if tc.txtRecords == 0 {
+						// RFC 8482 minimal answer
+						m.Answer = append(m.Answer, &dns.HINFO{
+							Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeHINFO, Class: dns.ClassINET, Ttl: 300},
+							Cpu: "RFC8482",
+						})
+					}
+				default:
+					m.Authoritative = true
+				}
+				if r.IsEdns0() != nil {
+					m.SetEdns0(4096, true)
+				}
+				w.WriteMsg(m)
+			})
+
+			client := NewClient(false)
+			result := client.ProbeExposure(context.Background(), "example.com", server)
+
+			if len(result.Errors) > 0 {
+				t.Fatalf("Unexpected errors: %v", result.Errors)
+			}
+			if result.OpenRecursion != tc.openRecursion {
+				t.Errorf("Expected open recursion %v, got %v", tc.openRecursion, result.OpenRecursion)
+			}
+			if len(result.Amplification) != 2 {
+				t.Fatalf("Expected ANY and DNSKEY probes, got %+v", result.Amplification)
+			}
+			for _, probe := range result.Amplification {
+				if probe.RequestSize == 0 || probe.ResponseSize == 0 || probe.Factor <= 0 {
+					t.Errorf("Incomplete amplification probe %+v", probe)
+				}
+			}
+
+			var ids []string
+			for _, finding := range result.Findings {
+				ids = append(ids, finding.ID)
+				if finding.Severity == "" || len(finding.Evidence) == 0 {
+					t.Errorf("Finding %s lacks severity or evidence", finding.ID)
+				}
+			}
+			if strings.Join(ids, ",") != strings.Join(tc.findings, ",") {
+				t.Errorf("Expected findings %v, got %v", tc.findings, ids)
+			}
+		})
+	}
+}
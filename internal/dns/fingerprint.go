@@ -0,0 +1,253 @@
+package dns
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/miekg/dns"
+)
+
+// fieldPattern is a pattern over one fingerprint field. The "behaviors"
+// field holds every observed behavior, one per line.
+type fieldPattern struct {
+	field   string
+	pattern *regexp.Regexp
+}
+
+// softwareRule guesses nameserver software from fingerprint fields; every
+// pattern must match
+type softwareRule struct {
+	patterns []fieldPattern
+	software string
+}
+
+// identityFields name the host that answered: the SOA primary and contact,
+// and the server identity returned over CHAOS and NSID
+var identityFields = []string{"soa", "nsid", "idServer", "hostnameBind"}
+
+// providerPatterns identify managed DNS providers by their own host names,
+// which show up in the SOA or in the server identity even behind vanity
+// nameserver names
+var providerPatterns = []struct {
+	pattern  string
+	provider string
+}{
+	{`(?i)awsdns-hostmaster\.amazon\.com|\.awsdns-[0-9]+\.`, "Amazon Route 53"},
+	{`(?i)\bdns\.cloudflare\.com`, "Cloudflare"},
+	{`(?i)azuredns-hostmaster\.microsoft\.com|\.azure-dns\.`, "Azure DNS"},
+	{`(?i)cloud-dns-hostmaster\.google\.com`, "Google Cloud DNS"},
+	{`(?i)\bnsone\.net`, "NS1"},
+	{`(?i)\bultradns\.`, "UltraDNS"},
+	{`(?i)\bakam\.net`, "Akamai Edge DNS"},
+	{`(?i)\bdynect\.net`, "Oracle Dyn"},
+	{`(?i)\bdnsmadeeasy\.com`, "DNS Made Easy"},
+	{`(?i)\bdigitalocean\.com`, "DigitalOcean DNS"},
+	{`(?i)\blinode\.com`, "Linode DNS"},
+	{`(?i)\bdomaincontrol\.com`, "GoDaddy DNS"},
+	{`(?i)\bregistrar-servers\.com`, "Namecheap DNS"},
+	{`(?i)\bpch\.net\b`, "PCH"},
+}
+
+// softwareRules are checked in order, the first match wins. Version strings
+// identify self-hosted software and host names identify managed providers.
+// Servers that hide their version still differ in which identification
+// queries they answer by default: BIND answers hostname.bind but not
+// id.server unless server-id is set, PowerDNS answers id.server but not
+// hostname.bind.
+var softwareRules = buildSoftwareRules()
+
+// buildSoftwareRules lists the version rules, a provider rule for each
+// identity field and the behavior rules
+func buildSoftwareRules() []softwareRule {
+	rules := []softwareRule{
+		{[]fieldPattern{field("versionBind", `(?i)powerdns`)}, "PowerDNS"},
+		{[]fieldPattern{field("versionBind", `(?i)knot`)}, "Knot DNS"},
+		{[]fieldPattern{field("versionBind", `(?i)^nsd\b`)}, "NSD"},
+		{[]fieldPattern{field("versionBind", `(?i)unbound`)}, "Unbound"},
+		{[]fieldPattern{field("versionBind", `(?i)dnsmasq`)}, "dnsmasq"},
+		{[]fieldPattern{field("versionBind", `(?i)yadifa`)}, "YADIFA"},
+		{[]fieldPattern{field("versionBind", `(?i)coredns`)}, "CoreDNS"},
+		{[]fieldPattern{field("versionBind", `(?i)microsoft`)}, "Microsoft DNS"},
+		{[]fieldPattern{field("versionBind", `(?i)^(bind\s*)?9\.[0-9]+\.[0-9]+`)}, "BIND"},
+	}
+	for _, provider := range providerPatterns {
+		for _, name := range identityFields {
+			rules = append(rules, softwareRule{[]fieldPattern{field(name, provider.pattern)}, provider.provider})
+		}
+	}
+	return append(rules,
+		softwareRule{[]fieldPattern{field("hostnameBind", `.`), field("behaviors", `(?m)^id\.server: .*$`)}, "BIND"},
+		softwareRule{[]fieldPattern{field("idServer", `.`), field("behaviors", `(?m)^hostname\.bind: .*$`)}, "PowerDNS"},
+	)
+}
+
+// field compiles a pattern over one fingerprint field
+func field(name, pattern string) fieldPattern {
+	return fieldPattern{field: name, pattern: regexp.MustCompile(pattern)}
+}
+
+// FingerprintNameserver sends CHAOS identification queries and an NSID-enabled
+// SOA query for zone straight to an authoritative server (host:port) and
+// guesses which software or provider runs it
+func (c *Client) FingerprintNameserver(ctx context.Context, zone, server string) models.NameserverFingerprint {
+	fingerprint := models.NameserverFingerprint{Server: server}
+	client := &dns.Client{Timeout: 2 * time.Second}
+
+	// CHAOS-class identification queries
+	for _, name := range []string{"version.bind.", "hostname.bind.", "id.server."} {
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, dns.TypeTXT)
+		msg.Question[0].Qclass = dns.ClassCHAOS
+		msg.RecursionDesired = false
+
+		resp, _, err := client.ExchangeContext(ctx, msg, server)
+		if err != nil {
+			fingerprint.Behaviors = append(fingerprint.Behaviors, fmt.Sprintf("%s: no response", strings.TrimSuffix(name, ".")))
+			continue
+		}
+
+		text := chaosText(resp)
+		if text == "" {
+			fingerprint.Behaviors = append(fingerprint.Behaviors,
+				fmt.Sprintf("%s: %s", strings.TrimSuffix(name, "."), describeEmptyResponse(resp)))
+			continue
+		}
+
+		switch name {
+		case "version.bind.":
+			fingerprint.VersionBind = text
+		case "hostname.bind.":
+			fingerprint.HostnameBind = text
+		case "id.server.":
+			fingerprint.IDServer = text
+		}
+	}
+
+	// SOA query with the NSID option
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	msg.RecursionDesired = false
+	msg.SetEdns0(1232, false)
+	opt := msg.IsEdns0()
+	opt.Option = append(opt.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID})
+
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err != nil {
+		fingerprint.Error = fmt.Sprintf("SOA query failed: %v", err)
+	} else {
+		if !resp.Authoritative {
+			fingerprint.Behaviors = append(fingerprint.Behaviors, "soa: not authoritative")
+		}
+		for _, rr := range resp.Answer {
+			if soa, ok := rr.(*dns.SOA); ok {
+				fingerprint.SOA = ExtractValue(soa)
+			}
+		}
+		if respOpt := resp.IsEdns0(); respOpt != nil {
+			for _, option := range respOpt.Option {
+				if nsid, ok := option.(*dns.EDNS0_NSID); ok {
+					fingerprint.NSID = decodeNSID(nsid.Nsid)
+				}
+			}
+		} else {
+			fingerprint.Behaviors = append(fingerprint.Behaviors, "edns: not supported")
+		}
+	}
+
+	// Servers should answer an unknown EDNS version with BADVERS (RFC 6891)
+	msg = new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	msg.RecursionDesired = false
+	msg.SetEdns0(1232, false)
+	msg.IsEdns0().SetVersion(1)
+	if resp, _, err := client.ExchangeContext(ctx, msg, server); err == nil && resp.Rcode != dns.RcodeBadVers {
+		fingerprint.Behaviors = append(fingerprint.Behaviors, fmt.Sprintf("edns1: %s instead of BADVERS", rcodeName(resp.Rcode)))
+	}
+
+	guessSoftware(&fingerprint)
+	return fingerprint
+}
+
+// guessSoftware applies the software rules to a fingerprint
+func guessSoftware(fingerprint *models.NameserverFingerprint) {
+	fields := map[string]string{
+		"versionBind":  fingerprint.VersionBind,
+		"hostnameBind": fingerprint.HostnameBind,
+		"idServer":     fingerprint.IDServer,
+		"nsid":         fingerprint.NSID,
+		"soa":          fingerprint.SOA,
+		"behaviors":    strings.Join(fingerprint.Behaviors, "\n"),
+	}
+
+	for _, rule := range softwareRules {
+		var evidence []string
+		for _, fp := range rule.patterns {
+			value := fields[fp.field]
+			match := fp.pattern.FindString(value)
+			if value == "" || match == "" {
+				evidence = nil
+				break
+			}
+			// A behavior is evidence on its own; other fields show their value
+			if fp.field == "behaviors" {
+				evidence = append(evidence, match)
+			} else {
+				evidence = append(evidence, fmt.Sprintf("%s: %s", fp.field, value))
+			}
+		}
+		if evidence != nil {
+			fingerprint.Software = rule.software
+			fingerprint.Evidence = append(fingerprint.Evidence, evidence...)
+			return
+		}
+	}
+}
+
+// chaosText returns the TXT answer of a CHAOS query
+func chaosText(resp *dns.Msg) string {
+	if resp.Rcode != dns.RcodeSuccess {
+		return ""
+	}
+	for _, rr := range resp.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			return strings.Join(txt.Txt, " ")
+		}
+	}
+	return ""
+}
+
+// describeEmptyResponse summarizes a response without a usable answer
+func describeEmptyResponse(resp *dns.Msg) string {
+	if resp.Rcode == dns.RcodeSuccess {
+		return "empty answer"
+	}
+	return rcodeName(resp.Rcode)
+}
+
+// rcodeName returns the mnemonic of a response code
+func rcodeName(rcode int) string {
+	if name, ok := dns.RcodeToString[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// decodeNSID turns the hex NSID payload into text when it is printable
+func decodeNSID(nsid string) string {
+	decoded, err := hex.DecodeString(nsid)
+	if err != nil {
+		return nsid
+	}
+	for _, r := range string(decoded) {
+		if !unicode.IsPrint(r) {
+			return nsid
+		}
+	}
+	return string(decoded)
+}
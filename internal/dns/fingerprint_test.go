@@ -0,0 +1,150 @@
+package dns
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// startTestServer runs a UDP nameserver on a random local port
+func startTestServer(t *testing.T, handler dns.HandlerFunc) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+
+	return conn.LocalAddr().String()
+}
+
+func TestFingerprintNameserver(t *testing.T) {
+	testCases := []struct {
+		name        string
+		version     string
+		hostname    string
+		idServer    string
+		nsid        string
+		soa         string
+		software    string
+		versionBind string
+	}{
+		{
+			name:        "BIND version string",
+			version:     "9.18.24-1-Debian",
+			soa:         "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+			software:    "BIND",
+			versionBind: "9.18.24-1-Debian",
+		},
+		{
+			name:     "Hidden version, provider SOA",
+			nsid:     "lax-edge-3",
+			soa:      "ns-1.awsdns-01.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400",
+			software: "Amazon Route 53",
+		},
+		{
+			name:     "Hidden version, provider NSID",
+			nsid:     "ns-1234.awsdns-22.co.uk",
+			soa:      "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+			software: "Amazon Route 53",
+		},
+		{
+			name:     "Hidden version, provider id.server",
+			idServer: "fra1.dns.cloudflare.com",
+			soa:      "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+			software: "Cloudflare",
+		},
+		{
+			name:     "Hidden version, hostname.bind only",
+			hostname: "ns1",
+			soa:      "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+			software: "BIND",
+		},
+		{
+			name:     "Hidden version, id.server only",
+			idServer: "ns1",
+			soa:      "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+			software: "PowerDNS",
+		},
+		{
+			name: "Hidden version and identity",
+			soa:  "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := startTestServer(t, func(w dns.ResponseWriter, r *dns.Msg) {
+				m := new(dns.Msg)
+				m.SetReply(r)
+				q := r.Question[0]
+
+				chaos := map[string]string{"version.bind.": tc.version, "hostname.bind.": tc.hostname, "id.server.": tc.idServer}
+
+				switch {
+				case q.Qclass == dns.ClassCHAOS && chaos[q.Name] != "":
+					m.Answer = append(m.Answer, &dns.TXT{
+						Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassCHAOS},
+						Txt: []string{chaos[q.Name]},
+					})
+				case q.Qclass == dns.ClassCHAOS:
+					m.Rcode = dns.RcodeRefused
+				case q.Qtype == dns.TypeSOA:
+					if opt := r.IsEdns0(); opt != nil && opt.Version() != 0 {
+						m.Rcode = dns.RcodeBadVers
+						m.SetEdns0(1232, false)
+						break
+					}
+					m.Authoritative = true
+					rr, err := dns.NewRR(q.Name + " 300 IN SOA " + tc.soa)
+					if err != nil {
+						t.Errorf("Bad SOA fixture: %v", err)
+					} else {
+						m.Answer = append(m.Answer, rr)
+					}
+					if r.IsEdns0() != nil {
+						m.SetEdns0(1232, false)
+						if tc.nsid != "" {
+							opt := m.IsEdns0()
+							opt.Option = append(opt.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: hex.EncodeToString([]byte(tc.nsid))})
+						}
+					}
+				}
+				w.WriteMsg(m)
+			})
+
+			client := NewClient(false)
+			result := client.FingerprintNameserver(context.Background(), "example.com", server)
+
+			if result.Error != "" {
+				t.Fatalf("Unexpected error: %s", result.Error)
+			}
+			if result.Software != tc.software {
+				t.Errorf("Expected software %q, got %q (evidence %v)", tc.software, result.Software, result.Evidence)
+			}
+			if result.VersionBind != tc.versionBind {
+				t.Errorf("Expected version.bind %q, got %q", tc.versionBind, result.VersionBind)
+			}
+			if result.NSID != tc.nsid {
+				t.Errorf("Expected NSID %q, got %q", tc.nsid, result.NSID)
+			}
+			if result.SOA == "" {
+				t.Errorf("Expected SOA to be recorded")
+			}
+			for _, behavior := range result.Behaviors {
+				if behavior == "soa: not authoritative" || behavior == "edns: not supported" {
+					t.Errorf("Unexpected behavior %q", behavior)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/miekg/dns"
+)
+
+const (
+	// iterativeResolverName stands in for a resolver address when a Client
+	// resolves iteratively
+	iterativeResolverName = "iterative"
+
+	// maxIterations bounds the referrals and CNAME hops followed for one name
+	maxIterations = 24
+	// maxGluelessDepth bounds nested resolutions of nameserver names that
+	// came without glue
+	maxGluelessDepth = 3
+)
+
+// DefaultRootHints are the IPv4 addresses of the 13 root servers
+var DefaultRootHints = []string{
+	"198.41.0.4",     // a.root-servers.net
+	"170.247.170.2",  // b.root-servers.net
+	"192.33.4.12",    // c.root-servers.net
+	"199.7.91.13",    // d.root-servers.net
+	"192.203.230.10", // e.root-servers.net
+	"192.5.5.241",    // f.root-servers.net
+	"192.112.36.4",   // g.root-servers.net
+	"198.97.190.53",  // h.root-servers.net
+	"192.36.148.17",  // i.root-servers.net
+	"192.58.128.30",  // j.root-servers.net
+	"193.0.14.129",   // k.root-servers.net
+	"199.7.83.42",    // l.root-servers.net
+	"202.12.27.33",   // m.root-servers.net
+}
+
+// IterativeResolver resolves names by following referrals from the root
+// servers instead of asking a recursive resolver. Delegations it learns are
+// cached so later names start at the closest known zone.
+type IterativeResolver struct {
+	rootHints []string
+	// port is used for the nameservers learned from referrals. Tests point
+	// it at the port their fake hierarchy listens on.
+	port  string
+	debug bool
+
+	mutex  sync.Mutex
+	zones  map[string][]string
+	traces []models.ResolutionTrace
+	traced map[string]bool
+}
+
+// NewIterativeResolver creates a resolver that starts at the given root hints.
+// Hints are IP addresses, optionally with a port (53 by default).
+func NewIterativeResolver(debug bool, rootHints []string) *IterativeResolver {
+	if len(rootHints) == 0 {
+		rootHints = DefaultRootHints
+	}
+
+	var addresses []string
+	for _, hint := range rootHints {
+		if _, _, err := net.SplitHostPort(hint); err == nil {
+			addresses = append(addresses, hint)
+			continue
+		}
+		addresses = append(addresses, net.JoinHostPort(hint, "53"))
+	}
+
+	return &IterativeResolver{
+		rootHints: addresses,
+		port:      "53",
+		debug:     debug,
+		zones:     make(map[string][]string),
+		traced:    make(map[string]bool),
+	}
+}
+
+// NewIterativeClient creates a DNS client that resolves every query
+// iteratively from the given root hints (DefaultRootHints if empty)
+func NewIterativeClient(debug bool, rootHints []string) *Client {
+	client := NewClient(debug)
+	client.iterative = NewIterativeResolver(debug, rootHints)
+	client.resolvers = []string{iterativeResolverName}
+	return client
+}
+
+// Traces returns the resolution traces recorded so far: one per delegation
+// path followed, plus every failed resolution
+func (r *IterativeResolver) Traces() []models.ResolutionTrace {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]models.ResolutionTrace(nil), r.traces...)
+}
+
+// Exchange resolves the question of msg iteratively and returns a response
+// as a recursive resolver would have sent it
+func (r *IterativeResolver) Exchange(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	if len(msg.Question) == 0 {
+		return nil, fmt.Errorf("query has no question")
+	}
+	question := msg.Question[0]
+
+	trace := models.ResolutionTrace{
+		Name: question.Name,
+		Type: RecordTypeToString(question.Qtype),
+	}
+	answers, rcode, err := r.resolve(ctx, question.Name, question.Qtype, 0, &trace)
+	if err != nil {
+		trace.Error = err.Error()
+	}
+
+	// Once a path is cached, later names down it are answered without a
+	// referral and only add noise to the trace
+	key := referralPath(trace)
+	r.mutex.Lock()
+	if err != nil || (key != "" && !r.traced[key]) {
+		r.traced[key] = true
+		r.traces = append(r.traces, trace)
+	}
+	r.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(dns.Msg)
+	resp.SetReply(msg)
+	resp.RecursionAvailable = true
+	resp.Rcode = rcode
+	resp.Answer = answers
+	return resp, nil
+}
+
+// resolve follows referrals and CNAMEs for name until it finds an answer, a
+// negative answer or runs out of servers
+func (r *IterativeResolver) resolve(ctx context.Context, name string, qtype uint16, depth int, trace *models.ResolutionTrace) ([]dns.RR, int, error) {
+	name = dns.Fqdn(strings.ToLower(name))
+	zone, servers := r.closestZone(name)
+
+	var answers []dns.RR
+	for i := 0; i < maxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, dns.RcodeServerFailure, err
+		}
+
+		resp, server, err := r.query(ctx, zone, servers, name, qtype, trace)
+		if err != nil {
+			return nil, dns.RcodeServerFailure, err
+		}
+		step := &trace.Steps[len(trace.Steps)-1]
+
+		if resp.Rcode == dns.RcodeNameError {
+			return answers, dns.RcodeNameError, nil
+		}
+
+		// An answer, possibly the start of a CNAME chain
+		var cname string
+		matched := false
+		for _, rr := range resp.Answer {
+			if !strings.EqualFold(rr.Header().Name, name) {
+				continue
+			}
+			if rr.Header().Rrtype == qtype || qtype == dns.TypeANY {
+				answers = append(answers, rr)
+				matched = true
+			} else if c, ok := rr.(*dns.CNAME); ok {
+				answers = append(answers, rr)
+				cname = c.Target
+			}
+		}
+		if matched {
+			return answers, dns.RcodeSuccess, nil
+		}
+		if cname != "" {
+			name = dns.Fqdn(strings.ToLower(cname))
+			zone, servers = r.closestZone(name)
+			continue
+		}
+		if resp.Authoritative {
+			// The name exists but has no records of this type
+			return answers, dns.RcodeSuccess, nil
+		}
+
+		// A referral to a zone closer to the name
+		child, nameservers := referral(resp, zone, name)
+		if child == "" {
+			return nil, dns.RcodeServerFailure, fmt.Errorf("%s (%s) sent neither an answer nor a referral for %s", server, zone, name)
+		}
+		step.Referral = child
+		step.Nameservers = nameservers
+
+		addresses := r.glueAddresses(resp, nameservers)
+		if len(addresses) == 0 && depth < maxGluelessDepth {
+			for _, nameserver := range nameservers {
+				resolved, _, err := r.resolve(ctx, nameserver, dns.TypeA, depth+1, trace)
+				if err != nil {
+					continue
+				}
+				for _, rr := range resolved {
+					if a, ok := rr.(*dns.A); ok {
+						addresses = append(addresses, net.JoinHostPort(a.A.String(), r.port))
+					}
+				}
+				if len(addresses) > 0 {
+					break
+				}
+			}
+		}
+		if len(addresses) == 0 {
+			return nil, dns.RcodeServerFailure, fmt.Errorf("no address found for any nameserver of %s", child)
+		}
+
+		r.mutex.Lock()
+		r.zones[child] = addresses
+		r.mutex.Unlock()
+
+		zone, servers = child, addresses
+	}
+
+	return nil, dns.RcodeServerFailure, fmt.Errorf("too many referrals resolving %s", name)
+}
+
+// query sends the question to each server of a zone in turn and records a
+// trace step per server tried
+func (r *IterativeResolver) query(ctx context.Context, zone string, servers []string, name string, qtype uint16, trace *models.ResolutionTrace) (*dns.Msg, string, error) {
+	client := &dns.Client{Timeout: 2 * time.Second}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, qtype)
+	msg.RecursionDesired = false
+	msg.SetEdns0(1232, false)
+
+	var lastErr error
+	for _, server := range servers {
+		step := models.TraceStep{Zone: zone, Server: server, Name: name}
+
+		resp, _, err := client.ExchangeContext(ctx, msg, server)
+		if err == nil && resp.Truncated {
+			tcpClient := &dns.Client{Net: "tcp", Timeout: client.Timeout}
+			resp, _, err = tcpClient.ExchangeContext(ctx, msg, server)
+		}
+		if err != nil {
+			step.Error = err.Error()
+			trace.Steps = append(trace.Steps, step)
+			lastErr = err
+			continue
+		}
+
+		step.Rcode = rcodeName(resp.Rcode)
+		step.Authoritative = resp.Authoritative
+		for _, rr := range resp.Answer {
+			step.Answers = append(step.Answers, fmt.Sprintf("%s %s", RecordTypeToString(rr.Header().Rrtype), ExtractValue(rr)))
+		}
+		trace.Steps = append(trace.Steps, step)
+
+		if r.debug {
+			fmt.Printf("[DEBUG] Iterative %s %s via %s (%s) - Rcode: %d, Answer records: %d\n",
+				name, RecordTypeToString(qtype), server, zone, resp.Rcode, len(resp.Answer))
+		}
+
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s answered %s", server, rcodeName(resp.Rcode))
+			continue
+		}
+		return resp, server, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no servers for %s", zone)
+	}
+	return nil, "", fmt.Errorf("no %s server answered for %s: %v", zone, name, lastErr)
+}
+
+// referralPath returns the zones a trace was referred to, in order
+func referralPath(trace models.ResolutionTrace) string {
+	var zones []string
+	for _, step := range trace.Steps {
+		if step.Referral != "" {
+			zones = append(zones, step.Referral)
+		}
+	}
+	return strings.Join(zones, " ")
+}
+
+// closestZone returns the deepest cached zone enclosing name and its servers
+func (r *IterativeResolver) closestZone(name string) (string, []string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for current := name; ; {
+		if servers, ok := r.zones[current]; ok {
+			return current, servers
+		}
+		offset, end := dns.NextLabel(current, 0)
+		if end {
+			break
+		}
+		current = current[offset:]
+	}
+	return ".", r.rootHints
+}
+
+// referral returns the child zone and nameserver names of a referral response
+// if it moves closer to name than zone
+func referral(resp *dns.Msg, zone, name string) (string, []string) {
+	var child string
+	var nameservers []string
+	for _, rr := range resp.Ns {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+		owner := strings.ToLower(ns.Hdr.Name)
+		if owner == zone || !dns.IsSubDomain(zone, owner) || !dns.IsSubDomain(owner, name) {
+			continue
+		}
+		if child != "" && owner != child {
+			continue
+		}
+		child = owner
+		nameservers = append(nameservers, strings.ToLower(ns.Ns))
+	}
+	return child, nameservers
+}
+
+// glueAddresses returns the IPv4 glue addresses for the given nameservers
+func (r *IterativeResolver) glueAddresses(resp *dns.Msg, nameservers []string) []string {
+	var addresses []string
+	for _, rr := range resp.Extra {
+		a, ok := rr.(*dns.A)
+		if !ok {
+			continue
+		}
+		for _, nameserver := range nameservers {
+			if strings.EqualFold(a.Hdr.Name, nameserver) {
+				addresses = append(addresses, net.JoinHostPort(a.A.String(), r.port))
+			}
+		}
+	}
+	return addresses
+}
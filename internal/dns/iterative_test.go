@@ -0,0 +1,267 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// fakeZone is a server in the test hierarchy: records it answers
+// authoritatively and delegations it refers queries to
+type fakeZone struct {
+	origin      string
+	records     []string
+	delegations []string
+	glue        []string
+}
+
+func (z fakeZone) handle(t *testing.T) dns.HandlerFunc {
+	parse := func(records []string) []dns.RR {
+		var rrs []dns.RR
+		for _, record := range records {
+			rr, err := dns.NewRR(record)
+			if err != nil {
+				t.Fatalf("Bad fixture %q: %v", record, err)
+			}
+			rrs = append(rrs, rr)
+		}
+		return rrs
+	}
+	records, delegations, glue := parse(z.records), parse(z.delegations), parse(z.glue)
+
+	return func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		q := r.Question[0]
+
+		for _, rr := range delegations {
+			if dns.IsSubDomain(rr.Header().Name, q.Name) {
+				m.Ns = append(m.Ns, rr)
+			}
+		}
+		if len(m.Ns) > 0 {
+			m.Extra = glue
+			w.WriteMsg(m)
+			return
+		}
+
+		m.Authoritative = true
+		exists := false
+		for _, rr := range records {
+			if !strings.EqualFold(rr.Header().Name, q.Name) {
+				continue
+			}
+			exists = true
+			if rr.Header().Rrtype == q.Qtype || rr.Header().Rrtype == dns.TypeCNAME {
+				m.Answer = append(m.Answer, rr)
+			}
+		}
+		if !exists {
+			m.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(m)
+	}
+}
+
+// startHierarchy runs a root, a "test." TLD and an "example.test."
+// authoritative server on 127.0.0.1-3 sharing one port
+func startHierarchy(t *testing.T) string {
+	t.Helper()
+
+	zones := []fakeZone{
+		{
+			origin:      ".",
+			delegations: []string{"test. 172800 IN NS ns.nic.test."},
+			glue:        []string{"ns.nic.test. 172800 IN A 127.0.0.2"},
+		},
+		{
+			origin: "test.",
+			delegations: []string{
+				"example.test. 86400 IN NS ns1.example.test.",
+				"other.test. 86400 IN NS ns.example.test.",
+			},
+			glue: []string{"ns1.example.test. 86400 IN A 127.0.0.3"},
+		},
+		{
+			origin: "example.test.",
+			records: []string{
+				"example.test. 300 IN A 192.0.2.10",
+				"example.test. 300 IN MX 10 mail.example.test.",
+				"www.example.test. 300 IN CNAME example.test.",
+				"autodiscover.example.test. 300 IN CNAME mail.example.test.",
+				"mail.example.test. 300 IN A 192.0.2.25",
+				"_acme-challenge.example.test. 300 IN TXT \"challenge-token\"",
+				"ns.example.test. 300 IN A 127.0.0.3",
+				"other.test. 300 IN MX 10 mail.other.test.",
+			},
+		},
+	}
+
+	var port string
+	for i, zone := range zones {
+		address := net.JoinHostPort("127.0.0."+string(rune('1'+i)), "0")
+		if port != "" {
+			address = net.JoinHostPort("127.0.0."+string(rune('1'+i)), port)
+		}
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			t.Skipf("Cannot listen on %s: %v", address, err)
+		}
+		if port == "" {
+			_, port, _ = net.SplitHostPort(conn.LocalAddr().String())
+		}
+
+		started := make(chan struct{})
+		server := &dns.Server{PacketConn: conn, Handler: zone.handle(t), NotifyStartedFunc: func() { close(started) }}
+		go server.ActivateAndServe()
+		<-started
+		t.Cleanup(func() { server.Shutdown() })
+	}
+
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// newHierarchyClient creates an iterative client for the test hierarchy,
+// which serves every zone on the root hint's port
+func newHierarchyClient(root string) *Client {
+	client := NewIterativeClient(false, []string{root})
+	_, client.iterative.port, _ = net.SplitHostPort(root)
+	return client
+}
+
+func TestIterativeLookup(t *testing.T) {
+	root := startHierarchy(t)
+
+	testCases := []struct {
+		name       string
+		query      string
+		recordType string
+		values     []string
+		steps      int
+	}{
+		{
+			name:       "Referrals from the root",
+			query:      "example.test",
+			recordType: "A",
+			values:     []string{"example.test. 192.0.2.10"},
+			steps:      3,
+		},
+		{
+			name:       "CNAME chain",
+			query:      "www.example.test",
+			recordType: "A",
+			values:     []string{"www.example.test. example.test.", "example.test. 192.0.2.10"},
+			steps:      4,
+		},
+		{
+			name:       "Nonexistent name",
+			query:      "missing.example.test",
+			recordType: "TXT",
+			steps:      3,
+		},
+		{
+			name:       "Delegation without glue",
+			query:      "other.test",
+			recordType: "MX",
+			values:     []string{"other.test. 10 mail.other.test."},
+			steps:      5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// A fresh client per case so every trace starts at the root
+			client := newHierarchyClient(root)
+
+			responses, err := client.Lookup(context.Background(), tc.query, tc.recordType)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// Every record keeps its own owner name, down the CNAME chain too
+			var values []string
+			for _, response := range responses {
+				values = append(values, response.Name+" "+response.Value)
+			}
+			if strings.Join(values, ",") != strings.Join(tc.values, ",") {
+				t.Errorf("Expected values %v, got %v", tc.values, values)
+			}
+
+			traces := client.Traces()
+			if len(traces) != 1 {
+				t.Fatalf("Expected one trace, got %d", len(traces))
+			}
+			if len(traces[0].Steps) != tc.steps {
+				t.Errorf("Expected %d steps, got %+v", tc.steps, traces[0].Steps)
+			}
+			if first := traces[0].Steps[0]; first.Zone != "." || first.Server != root {
+				t.Errorf("Expected the trace to start at the root hint, got %+v", first)
+			}
+		})
+	}
+}
+
+func TestIterativeTracePerPath(t *testing.T) {
+	client := newHierarchyClient(startHierarchy(t))
+
+	// Later names down a cached path add no trace, a new path does
+	for _, query := range []struct{ name, recordType string }{
+		{"example.test", "A"},
+		{"example.test", "MX"},
+		{"www.example.test", "A"},
+		{"other.test", "MX"},
+	} {
+		if _, err := client.Lookup(context.Background(), query.name, query.recordType); err != nil {
+			t.Fatalf("Unexpected error looking up %s %s: %v", query.name, query.recordType, err)
+		}
+	}
+
+	var names []string
+	for _, trace := range client.Traces() {
+		names = append(names, trace.Name+" "+trace.Type)
+	}
+	expected := []string{"example.test. A", "other.test. MX"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected traces %v, got %v", expected, names)
+	}
+}
+
+func TestQueryAllRecordsOwnerNames(t *testing.T) {
+	client := newHierarchyClient(startHierarchy(t))
+
+	records, err := client.QueryAllRecords(context.Background(), "example.test.", 5*time.Second, 1000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	collected := make(map[string]bool)
+	for _, record := range records {
+		collected[record.Name+" "+record.RecordType+" "+record.Value] = true
+	}
+	for _, expected := range []string{
+		"example.test. A 192.0.2.10",
+		"autodiscover.example.test. CNAME mail.example.test.",
+		"mail.example.test. A 192.0.2.25",
+		"_acme-challenge.example.test. TXT challenge-token",
+	} {
+		if !collected[expected] {
+			t.Errorf("Expected record %q, got %v", expected, records)
+		}
+	}
+}
+
+func TestRootHintPorts(t *testing.T) {
+	resolver := NewIterativeResolver(false, []string{"192.0.2.1:5353", "192.0.2.2", "2001:db8::1"})
+
+	expected := []string{"192.0.2.1:5353", "192.0.2.2:53", "[2001:db8::1]:53"}
+	if strings.Join(resolver.rootHints, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected root hints %v, got %v", expected, resolver.rootHints)
+	}
+	if resolver.port != "53" {
+		t.Errorf("Expected referred nameservers on port 53, got %s", resolver.port)
+	}
+}
@@ -76,6 +76,9 @@ func GetRecordTypeMapping() map[uint16]string {
 		60:    "CDNSKEY",
 		61:    "OPENPGPKEY",
 		62:    "CSYNC",
+		63:    "ZONEMD",
+		64:    "SVCB",
+		65:    "HTTPS",
 		99:    "SPF",
 		100:   "UINFO",
 		101:   "UID",
@@ -132,11 +135,31 @@ func ExtractValue(rr dns.RR) string {
 		return fmt.Sprintf("%s %s", rr.NextDomain, typesToString(rr.TypeBitMap))
 	case *dns.TLSA:
 		return fmt.Sprintf("%d %d %d %s", rr.Usage, rr.Selector, rr.MatchingType, rr.Certificate)
+	case *dns.SVCB:
+		return svcbToString(rr)
+	case *dns.HTTPS:
+		return svcbToString(&rr.SVCB)
+	case *dns.ZONEMD:
+		return fmt.Sprintf("%d %d %d %s", rr.Serial, rr.Scheme, rr.Hash, rr.Digest)
 	default:
 		return rr.String()
 	}
 }
 
+// svcbToString renders an SVCB or HTTPS record as "priority target key=value ...",
+// leaving parameter values unquoted so signatures can match them directly
+func svcbToString(rr *dns.SVCB) string {
+	parts := []string{fmt.Sprintf("%d %s", rr.Priority, rr.Target)}
+	for _, kv := range rr.Value {
+		if value := kv.String(); value != "" {
+			parts = append(parts, kv.Key().String()+"="+value)
+		} else {
+			parts = append(parts, kv.Key().String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // typesToString converts a slice of record types to a string
 func typesToString(types []uint16) string {
 	var strs []string
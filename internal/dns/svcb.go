@@ -0,0 +1,98 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/miekg/dns"
+)
+
+// NewServiceBinding reads the SvcParams of an SVCB or HTTPS record. It
+// returns nil for other record types.
+func NewServiceBinding(rr dns.RR) *models.ServiceBinding {
+	var svcb *dns.SVCB
+	switch rr := rr.(type) {
+	case *dns.SVCB:
+		svcb = rr
+	case *dns.HTTPS:
+		svcb = &rr.SVCB
+	default:
+		return nil
+	}
+
+	binding := &models.ServiceBinding{
+		Name:       strings.ToLower(rr.Header().Name),
+		RecordType: RecordTypeToString(rr.Header().Rrtype),
+		Priority:   svcb.Priority,
+		Target:     svcb.Target,
+		AliasMode:  svcb.Priority == 0,
+	}
+
+	for _, kv := range svcb.Value {
+		switch kv := kv.(type) {
+		case *dns.SVCBAlpn:
+			binding.ALPN = append(binding.ALPN, kv.Alpn...)
+		case *dns.SVCBNoDefaultAlpn:
+			binding.NoDefaultALPN = true
+		case *dns.SVCBPort:
+			binding.Port = kv.Port
+		case *dns.SVCBIPv4Hint:
+			for _, ip := range kv.Hint {
+				binding.IPv4Hints = append(binding.IPv4Hints, ip.String())
+			}
+		case *dns.SVCBIPv6Hint:
+			for _, ip := range kv.Hint {
+				binding.IPv6Hints = append(binding.IPv6Hints, ip.String())
+			}
+		case *dns.SVCBECHConfig:
+			binding.ECHConfig = kv.String()
+		case *dns.SVCBMandatory:
+			for _, key := range kv.Code {
+				binding.Mandatory = append(binding.Mandatory, key.String())
+			}
+		case *dns.SVCBLocal:
+			// Unknown keys keep their raw value, not its escaped presentation
+			setOtherParam(binding, kv.Key().String(), string(kv.Data))
+		default:
+			setOtherParam(binding, kv.Key().String(), kv.String())
+		}
+	}
+
+	for _, protocol := range binding.ALPN {
+		if protocol == "h3" || strings.HasPrefix(protocol, "h3-") {
+			binding.HTTP3 = true
+		}
+	}
+	binding.ECH = binding.ECHConfig != ""
+
+	return binding
+}
+
+// setOtherParam records a SvcParam without a field of its own
+func setOtherParam(binding *models.ServiceBinding, key, value string) {
+	if binding.OtherParams == nil {
+		binding.OtherParams = make(map[string]string)
+	}
+	binding.OtherParams[key] = value
+}
+
+// ParseServiceBinding returns the SvcParams of a collected SVCB or HTTPS
+// record. Records keep the binding read from the record as received; only
+// records loaded from saved results are parsed again from their value.
+func ParseServiceBinding(record models.DNSResponse) (models.ServiceBinding, error) {
+	if record.ServiceBinding != nil {
+		return *record.ServiceBinding, nil
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(record.Owner()), record.TTL, record.RecordType, record.Value))
+	if err != nil {
+		return models.ServiceBinding{}, fmt.Errorf("error parsing %s record %q: %v", record.RecordType, record.Value, err)
+	}
+	binding := NewServiceBinding(rr)
+	if binding == nil {
+		return models.ServiceBinding{}, fmt.Errorf("%s is not a service binding record type", record.RecordType)
+	}
+	binding.Name = record.Owner()
+	return *binding, nil
+}
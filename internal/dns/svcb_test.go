@@ -0,0 +1,137 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+	"github.com/miekg/dns"
+)
+
+func TestParseServiceBinding(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rr       string
+		value    string
+		expected models.ServiceBinding
+	}{
+		{
+			name:  "HTTPS service mode",
+			rr:    `example.com. 300 IN HTTPS 1 . alpn="h3,h2" ipv4hint=104.16.1.1,104.16.2.2 ech=AEX+DQA=`,
+			value: "1 . alpn=h3,h2 ipv4hint=104.16.1.1,104.16.2.2 ech=AEX+DQA=",
+			expected: models.ServiceBinding{
+				Name:       "example.com.",
+				RecordType: "HTTPS",
+				Priority:   1,
+				Target:     ".",
+				ALPN:       []string{"h3", "h2"},
+				IPv4Hints:  []string{"104.16.1.1", "104.16.2.2"},
+				ECHConfig:  "AEX+DQA=",
+				HTTP3:      true,
+				ECH:        true,
+			},
+		},
+		{
+			name:  "HTTPS alias mode",
+			rr:    `example.com. 300 IN HTTPS 0 cdn.example.net.`,
+			value: "0 cdn.example.net.",
+			expected: models.ServiceBinding{
+				Name:       "example.com.",
+				RecordType: "HTTPS",
+				Target:     "cdn.example.net.",
+				AliasMode:  true,
+			},
+		},
+		{
+			name:  "SVCB with port and no-default-alpn",
+			rr:    `_dns.example.com. 300 IN SVCB 2 dns.example.com. alpn=dot port=853 no-default-alpn`,
+			value: "2 dns.example.com. alpn=dot port=853 no-default-alpn",
+			expected: models.ServiceBinding{
+				Name:          "_dns.example.com.",
+				RecordType:    "SVCB",
+				Priority:      2,
+				Target:        "dns.example.com.",
+				ALPN:          []string{"dot"},
+				NoDefaultALPN: true,
+				Port:          853,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr, err := dns.NewRR(tc.rr)
+			if err != nil {
+				t.Fatalf("Error parsing test record: %v", err)
+			}
+
+			record := models.DNSResponse{
+				Domain:     rr.Header().Name,
+				RecordType: RecordTypeToString(rr.Header().Rrtype),
+				TTL:        rr.Header().Ttl,
+				Value:      ExtractValue(rr),
+			}
+			if record.Value != tc.value {
+				t.Errorf("Expected value %q, got %q", tc.value, record.Value)
+			}
+
+			binding, err := ParseServiceBinding(record)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(binding, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, binding)
+			}
+		})
+	}
+}
+
+func TestNewServiceBinding(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rr       string
+		expected *models.ServiceBinding
+	}{
+		{
+			name: "Quoted and escaped values",
+			rr:   `Example.com. 300 IN HTTPS 1 . alpn="h2,h3\\,x" mandatory=alpn,port port=8443 key65000="hello world" key65001="a\"b;c"`,
+			expected: &models.ServiceBinding{
+				Name:        "example.com.",
+				RecordType:  "HTTPS",
+				Priority:    1,
+				Target:      ".",
+				ALPN:        []string{"h2", "h3,x"},
+				Port:        8443,
+				Mandatory:   []string{"alpn", "port"},
+				OtherParams: map[string]string{"key65000": "hello world", "key65001": `a"b;c`},
+			},
+		},
+		{
+			name: "Not a service binding",
+			rr:   `example.com. 300 IN A 192.0.2.1`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr, err := dns.NewRR(tc.rr)
+			if err != nil {
+				t.Fatalf("Error parsing test record: %v", err)
+			}
+
+			binding := NewServiceBinding(rr)
+			if !reflect.DeepEqual(binding, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, binding)
+			}
+
+			// Collected records carry the binding, so it isn't parsed again
+			if binding != nil {
+				record := models.DNSResponse{Domain: "example.com.", RecordType: "HTTPS", Value: ExtractValue(rr), ServiceBinding: binding}
+				parsed, err := ParseServiceBinding(record)
+				if err != nil || !reflect.DeepEqual(parsed, *tc.expected) {
+					t.Errorf("Expected the collected binding %+v, got %+v (%v)", *tc.expected, parsed, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package dns
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// ParseZoneFile reads the records of an RFC 1035 zone file. Relative names
+// use origin until the file sets $ORIGIN. The zone's name is taken from its
+// SOA record, falling back to origin for files without one.
+func ParseZoneFile(path, origin string) (string, []models.DNSResponse, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("error opening zone file: %v", err)
+	}
+	defer file.Close()
+
+	return ParseZone(file, origin, path)
+}
+
+// ParseZone reads zone file data from r. name is used in error messages.
+func ParseZone(r io.Reader, origin, name string) (string, []models.DNSResponse, error) {
+	if origin != "" {
+		origin = dns.Fqdn(origin)
+	}
+	zone := strings.ToLower(origin)
+	seenSOA := false
+
+	parser := dns.NewZoneParser(r, origin, name)
+	var records []models.DNSResponse
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		owner := strings.ToLower(rr.Header().Name)
+		if _, isSOA := rr.(*dns.SOA); isSOA && !seenSOA {
+			zone, seenSOA = owner, true
+		}
+
+		value := ExtractValue(rr)
+		if value == "" {
+			continue
+		}
+		records = append(records, models.DNSResponse{
+			Domain:         owner,
+			Name:           owner,
+			RecordType:     RecordTypeToString(rr.Header().Rrtype),
+			TTL:            rr.Header().Ttl,
+			Value:          value,
+			ServiceBinding: NewServiceBinding(rr),
+		})
+	}
+	if err := parser.Err(); err != nil {
+		return "", nil, fmt.Errorf("error parsing zone file: %v", err)
+	}
+	if zone == "" {
+		return "", nil, fmt.Errorf("zone file %s has no SOA record and no origin was given", name)
+	}
+	return zone, records, nil
+}
@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+)
+
+const testZone = `$ORIGIN example.com.
+$TTL 3600
+@       IN SOA ns1.example.net. hostmaster.example.com. 2024010101 7200 900 1209600 300
+@       IN NS  ns1.example.net.
+@       IN MX  10 aspmx.l.google.com.
+@       IN TXT "v=spf1 include:_spf.google.com -all"
+_dmarc  IN TXT "v=DMARC1; p=reject"
+www     IN CNAME example.netlify.app.
+`
+
+func TestParseZone(t *testing.T) {
+	zone, records, err := ParseZone(strings.NewReader(testZone), "", "example.com.zone")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if zone != "example.com." {
+		t.Errorf("Expected zone example.com., got %s", zone)
+	}
+	if len(records) != 6 {
+		t.Fatalf("Expected 6 records, got %d: %v", len(records), records)
+	}
+
+	dmarc := records[4]
+	if dmarc.Owner() != "_dmarc.example.com." || dmarc.RecordType != "TXT" || dmarc.Value != "v=DMARC1; p=reject" || dmarc.TTL != 3600 {
+		t.Errorf("Unexpected record %+v", dmarc)
+	}
+
+	// Relative names without $ORIGIN need an origin, and the zone is named
+	// after it when there is no SOA
+	zone, records, err = ParseZone(strings.NewReader("www IN A 192.0.2.1\n"), "Example.org", "partial.zone")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if zone != "example.org." || records[0].Owner() != "www.example.org." {
+		t.Errorf("Unexpected zone %s and records %v", zone, records)
+	}
+
+	if _, _, err := ParseZone(strings.NewReader("www IN A 192.0.2.1\n"), "", "bare.zone"); err == nil {
+		t.Errorf("Expected a zone without SOA or origin to be rejected")
+	}
+	if _, _, err := ParseZone(strings.NewReader("@ IN A not-an-address\n"), "example.com", "broken.zone"); err == nil {
+		t.Errorf("Expected a malformed record to be rejected")
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "strings"
+
+// Baseline states of a detection. Approved technologies that aren't detected
+// are only listed in BaselineResult.Missing.
+const (
+	BaselineExpected   = "expected"
+	BaselineUnexpected = "unexpected"
+)
+
+// BaselineAll is the baseline key whose entry applies to every domain
+const BaselineAll = "*"
+
+// Baseline lists the technologies and categories approved for each domain
+type Baseline struct {
+	Generated string                   `json:"generated,omitempty"`
+	Domains   map[string]BaselineEntry `json:"domains"`
+}
+
+// BaselineEntry is what is approved for one domain. Technologies are also
+// expected to stay detected; categories only approve what falls in them.
+type BaselineEntry struct {
+	Technologies []string `json:"technologies,omitempty"`
+	Categories   []string `json:"categories,omitempty"`
+}
+
+// BaselineResult compares a domain's detections with its baseline
+type BaselineResult struct {
+	Expected   []string `json:"expected,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	Missing    []string `json:"missing,omitempty"`
+}
+
+// BaselineKey normalizes a domain for use as a baseline key
+func BaselineKey(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// Entry returns what is approved for a domain: its own entry combined with
+// the entry for every domain
+func (b Baseline) Entry(domain string) BaselineEntry {
+	entry := b.Domains[BaselineKey(domain)]
+	all := b.Domains[BaselineAll]
+	return BaselineEntry{
+		Technologies: append(append([]string(nil), entry.Technologies...), all.Technologies...),
+		Categories:   append(append([]string(nil), entry.Categories...), all.Categories...),
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+// CAAResult is the effective CAA policy for a scanned name (RFC 8659)
+type CAAResult struct {
+	Name                  string      `json:"name"`
+	PolicyDomain          string      `json:"policyDomain,omitempty"`
+	Records               []string    `json:"records,omitempty"`
+	Issue                 []CAAIssuer `json:"issue,omitempty"`
+	IssueWild             []CAAIssuer `json:"issueWild,omitempty"`
+	IODEF                 []string    `json:"iodef,omitempty"`
+	UnknownCritical       []string    `json:"unknownCritical,omitempty"`
+	AnyCA                 bool        `json:"anyCa"`
+	AuthorizedCAs         []string    `json:"authorizedCas,omitempty"`
+	AuthorizedWildcardCAs []string    `json:"authorizedWildcardCas,omitempty"`
+	Errors                []string    `json:"errors,omitempty"`
+	Warnings              []string    `json:"warnings,omitempty"`
+}
+
+// CAAIssuer is one issue or issuewild property
+type CAAIssuer struct {
+	Domain            string            `json:"domain"`
+	Name              string            `json:"name,omitempty"`
+	Critical          bool              `json:"critical,omitempty"`
+	AccountURI        string            `json:"accountUri,omitempty"`
+	ValidationMethods []string          `json:"validationMethods,omitempty"`
+	Parameters        map[string]string `json:"parameters,omitempty"`
+}
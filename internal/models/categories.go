@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Category is a node in the category taxonomy. Signatures refer to a
+// category by its ID, name or one of its aliases.
+type Category struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Parent  string   `json:"parent,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// CategoryFile contains the category taxonomy
+type CategoryFile struct {
+	Categories []Category `json:"categories"`
+}
+
+// Taxonomy is a validated category tree
+type Taxonomy struct {
+	categories map[string]Category
+	// lookup maps lowercase IDs, names and aliases to category IDs
+	lookup map[string]string
+}
+
+// NewTaxonomy checks that IDs are unique, parents exist, the tree has no
+// cycles and no name or alias refers to two categories
+func NewTaxonomy(file CategoryFile) (*Taxonomy, error) {
+	taxonomy := &Taxonomy{
+		categories: make(map[string]Category),
+		lookup:     make(map[string]string),
+	}
+
+	for _, category := range file.Categories {
+		if category.ID == "" || category.Name == "" {
+			return nil, fmt.Errorf("category %q needs both an id and a name", category.ID+category.Name)
+		}
+		if _, exists := taxonomy.categories[category.ID]; exists {
+			return nil, fmt.Errorf("duplicate category id %q", category.ID)
+		}
+		taxonomy.categories[category.ID] = category
+
+		for _, key := range append([]string{category.ID, category.Name}, category.Aliases...) {
+			folded := strings.ToLower(key)
+			if other, exists := taxonomy.lookup[folded]; exists && other != category.ID {
+				return nil, fmt.Errorf("category %q: %q already refers to category %q", category.ID, key, other)
+			}
+			taxonomy.lookup[folded] = category.ID
+		}
+	}
+
+	for _, category := range file.Categories {
+		seen := map[string]bool{category.ID: true}
+		for parent := category.Parent; parent != ""; parent = taxonomy.categories[parent].Parent {
+			if _, exists := taxonomy.categories[parent]; !exists {
+				return nil, fmt.Errorf("category %q: unknown parent %q", category.ID, parent)
+			}
+			if seen[parent] {
+				return nil, fmt.Errorf("category %q: parents form a cycle", category.ID)
+			}
+			seen[parent] = true
+		}
+	}
+
+	return taxonomy, nil
+}
+
+// Len returns the number of categories
+func (t *Taxonomy) Len() int {
+	return len(t.categories)
+}
+
+// Resolve finds a category by ID, name or alias, ignoring case
+func (t *Taxonomy) Resolve(name string) (Category, bool) {
+	id, ok := t.lookup[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Category{}, false
+	}
+	return t.categories[id], true
+}
+
+// Path returns the IDs from the root of the tree down to the category
+func (t *Taxonomy) Path(id string) []string {
+	var path []string
+	for current := id; current != ""; current = t.categories[current].Parent {
+		if _, exists := t.categories[current]; !exists {
+			break
+		}
+		path = append([]string{current}, path...)
+	}
+	return path
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTaxonomy(t *testing.T) {
+	taxonomy, err := NewTaxonomy(CategoryFile{Categories: []Category{
+		{ID: "email", Name: "Email"},
+		{ID: "email-security", Name: "Email Security", Parent: "email"},
+		{ID: "email-hosting", Name: "Email Hosting", Parent: "email", Aliases: []string{"Email & Collaboration"}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	category, ok := taxonomy.Resolve("email & collaboration")
+	if !ok || category.ID != "email-hosting" {
+		t.Errorf("Expected the alias to resolve to email-hosting, got %+v", category)
+	}
+	if _, ok := taxonomy.Resolve("Security"); ok {
+		t.Errorf("Expected an unknown category not to resolve")
+	}
+	if path := taxonomy.Path("email-security"); !reflect.DeepEqual(path, []string{"email", "email-security"}) {
+		t.Errorf("Unexpected path %v", path)
+	}
+
+	testCases := []struct {
+		name       string
+		categories []Category
+		err        string
+	}{
+		{"duplicate id", []Category{{ID: "a", Name: "A"}, {ID: "a", Name: "B"}}, "duplicate category id"},
+		{"unknown parent", []Category{{ID: "a", Name: "A", Parent: "b"}}, "unknown parent"},
+		{"cycle", []Category{{ID: "a", Name: "A", Parent: "b"}, {ID: "b", Name: "B", Parent: "a"}}, "cycle"},
+		{"alias of another category", []Category{{ID: "a", Name: "A"}, {ID: "b", Name: "B", Aliases: []string{"a"}}}, "already refers"},
+		{"missing name", []Category{{ID: "a"}}, "needs both"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTaxonomy(CategoryFile{Categories: tc.categories})
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
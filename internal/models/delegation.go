@@ -0,0 +1,25 @@
+package models
+
+// DelegationResult compares a zone's delegation in its parent zone with the
+// zone's own NS records and the answers of each nameserver
+type DelegationResult struct {
+	Zone         string              `json:"zone"`
+	ParentZone   string              `json:"parentZone,omitempty"`
+	ParentServer string              `json:"parentServer,omitempty"`
+	ParentNS     []string            `json:"parentNs,omitempty"`
+	ChildNS      []string            `json:"childNs,omitempty"`
+	Glue         map[string][]string `json:"glue,omitempty"`
+	Servers      []DelegationServer  `json:"servers,omitempty"`
+	Findings     []Finding           `json:"findings,omitempty"`
+	Errors       []string            `json:"errors,omitempty"`
+}
+
+// DelegationServer is the answer of one nameserver address to an SOA query
+// for the zone
+type DelegationServer struct {
+	Host          string `json:"host"`
+	Address       string `json:"address"`
+	Authoritative bool   `json:"authoritative"`
+	Serial        uint32 `json:"serial,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// Finding severities, from least to most serious
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Finding is a security issue found while analyzing a domain
+type Finding struct {
+	ID          string   `json:"id"`
+	Severity    string   `json:"severity"`
+	Description string   `json:"description"`
+	Evidence    []string `json:"evidence,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+}
+
+// Rule turns a condition over a domain's records into a finding. Rules are
+// loaded from the rules file, next to the technology signatures.
+type Rule struct {
+	ID          string    `json:"id"`
+	Severity    string    `json:"severity"`
+	Description string    `json:"description"`
+	Remediation string    `json:"remediation,omitempty"`
+	Condition   Condition `json:"condition"`
+}
+
+// RuleFile contains all misconfiguration rules
+type RuleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// ValidSeverity reports whether severity is one of the finding severities
+func ValidSeverity(severity string) bool {
+	switch severity {
+	case SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+	}
+	return false
+}
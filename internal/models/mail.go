@@ -0,0 +1,98 @@
+package models
+
+// SPFResult holds the outcome of evaluating a domain's SPF policy
+type SPFResult struct {
+	Record        string       `json:"record,omitempty"`
+	Status        string       `json:"status"`
+	AllQualifier  string       `json:"allQualifier,omitempty"`
+	DNSLookups    int          `json:"dnsLookups"`
+	VoidLookups   int          `json:"voidLookups"`
+	AuthorizedIPs []string     `json:"authorizedIPs,omitempty"`
+	Includes      []SPFInclude `json:"includes,omitempty"`
+	Providers     []string     `json:"providers,omitempty"`
+	Errors        []string     `json:"errors,omitempty"`
+	Warnings      []string     `json:"warnings,omitempty"`
+}
+
+// SPFInclude is one node of the include/redirect tree of an SPF policy
+type SPFInclude struct {
+	Mechanism string       `json:"mechanism"`
+	Domain    string       `json:"domain"`
+	Record    string       `json:"record,omitempty"`
+	Includes  []SPFInclude `json:"includes,omitempty"`
+}
+
+// DMARCResult holds the parsed DMARC policy of a domain
+type DMARCResult struct {
+	Record           string           `json:"record,omitempty"`
+	Status           string           `json:"status"`
+	Policy           string           `json:"policy,omitempty"`
+	SubdomainPolicy  string           `json:"subdomainPolicy,omitempty"`
+	Percentage       int              `json:"percentage"`
+	DKIMAlignment    string           `json:"dkimAlignment,omitempty"`
+	SPFAlignment     string           `json:"spfAlignment,omitempty"`
+	FailureOptions   []string         `json:"failureOptions,omitempty"`
+	ReportInterval   uint32           `json:"reportInterval,omitempty"`
+	AggregateReports []DMARCReportURI `json:"aggregateReports,omitempty"`
+	ForensicReports  []DMARCReportURI `json:"forensicReports,omitempty"`
+	Enforced         bool             `json:"enforced"`
+	InvalidTags      []string         `json:"invalidTags,omitempty"`
+	Errors           []string         `json:"errors,omitempty"`
+	Warnings         []string         `json:"warnings,omitempty"`
+}
+
+// DMARCReportURI is a rua or ruf reporting destination
+type DMARCReportURI struct {
+	URI        string `json:"uri"`
+	Domain     string `json:"domain,omitempty"`
+	MaxSize    string `json:"maxSize,omitempty"`
+	External   bool   `json:"external"`
+	Authorized bool   `json:"authorized"`
+}
+
+// MTASTSResult holds the MTA-STS DNS record and, when fetched, the HTTPS policy
+type MTASTSResult struct {
+	Record      string        `json:"record,omitempty"`
+	Status      string        `json:"status"`
+	ID          string        `json:"id,omitempty"`
+	PolicyURL   string        `json:"policyUrl,omitempty"`
+	Policy      *MTASTSPolicy `json:"policy,omitempty"`
+	UnmatchedMX []string      `json:"unmatchedMx,omitempty"`
+	Errors      []string      `json:"errors,omitempty"`
+	Warnings    []string      `json:"warnings,omitempty"`
+}
+
+// MTASTSPolicy is a parsed mta-sts.txt policy file
+type MTASTSPolicy struct {
+	Version string   `json:"version"`
+	Mode    string   `json:"mode"`
+	MX      []string `json:"mx"`
+	MaxAge  int      `json:"maxAge"`
+}
+
+// TLSRPTResult holds the SMTP TLS reporting record published at _smtp._tls
+type TLSRPTResult struct {
+	Record     string   `json:"record,omitempty"`
+	Status     string   `json:"status"`
+	ReportURIs []string `json:"reportUris,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// BIMIResult holds the BIMI assertion records found for a domain
+type BIMIResult struct {
+	Selectors     []BIMISelector `json:"selectors"`
+	DMARCEnforced bool           `json:"dmarcEnforced"`
+	Eligible      bool           `json:"eligible"`
+	Issues        []string       `json:"issues,omitempty"`
+}
+
+// BIMISelector is the BIMI record published at <selector>._bimi.<domain>
+type BIMISelector struct {
+	Selector     string   `json:"selector"`
+	Record       string   `json:"record,omitempty"`
+	Status       string   `json:"status"`
+	LogoURL      string   `json:"logoUrl,omitempty"`
+	AuthorityURL string   `json:"authorityUrl,omitempty"`
+	Errors       []string `json:"errors,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
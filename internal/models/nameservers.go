@@ -0,0 +1,44 @@
+package models
+
+// Nameserver is an authoritative nameserver of the analyzed domain
+type Nameserver struct {
+	Host        string                 `json:"host"`
+	Addresses   []string               `json:"addresses,omitempty"`
+	Fingerprint *NameserverFingerprint `json:"fingerprint,omitempty"`
+	Exposure    *NameserverExposure    `json:"exposure,omitempty"`
+}
+
+// NameserverFingerprint holds the identification answers returned by a
+// nameserver and the software guessed from them
+type NameserverFingerprint struct {
+	Server       string   `json:"server"`
+	VersionBind  string   `json:"versionBind,omitempty"`
+	HostnameBind string   `json:"hostnameBind,omitempty"`
+	IDServer     string   `json:"idServer,omitempty"`
+	NSID         string   `json:"nsid,omitempty"`
+	SOA          string   `json:"soa,omitempty"`
+	Behaviors    []string `json:"behaviors,omitempty"`
+	Software     string   `json:"software,omitempty"`
+	Evidence     []string `json:"evidence,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// NameserverExposure holds the results of probing a nameserver for open
+// recursion and reflection amplification
+type NameserverExposure struct {
+	Server        string               `json:"server"`
+	OpenRecursion bool                 `json:"openRecursion"`
+	Amplification []AmplificationProbe `json:"amplification,omitempty"`
+	Findings      []Finding            `json:"findings,omitempty"`
+	Errors        []string             `json:"errors,omitempty"`
+}
+
+// AmplificationProbe is the size of a nameserver's answer to one query
+// relative to the size of the query
+type AmplificationProbe struct {
+	QueryType    string  `json:"queryType"`
+	RequestSize  int     `json:"requestSize"`
+	ResponseSize int     `json:"responseSize"`
+	Factor       float64 `json:"factor"`
+	Truncated    bool    `json:"truncated,omitempty"`
+}
@@ -1,19 +1,95 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // DNSResponse holds the parsed DNS record data
 type DNSResponse struct {
-	Domain     string `json:"domain"`
+	// Domain is the name that was queried, which is the apex for the full
+	// record sweep
+	Domain string `json:"domain"`
+	// Name is the owner name of the record. It differs from Domain for
+	// records further down a CNAME chain.
+	Name       string `json:"name,omitempty"`
 	RecordType string `json:"recordType"`
 	TTL        uint32 `json:"ttl"`
 	Value      string `json:"value"`
+	// ServiceBinding holds the SvcParams of an SVCB or HTTPS record, read
+	// from the record as received. It isn't saved with the record.
+	ServiceBinding *ServiceBinding `json:"-"`
+}
+
+// Owner returns the owner name of the record, falling back to the queried
+// name for records saved before owner names were kept
+func (r DNSResponse) Owner() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Domain
 }
 
 // DetectedTechnology represents a detected technology instance
 type DetectedTechnology struct {
-	Name        string `json:"name"`
-	Category    string `json:"category"`
-	Description string `json:"description"`
-	Website     string `json:"website"`
-	Evidence    string `json:"evidence"`
-	RecordType  string `json:"recordType"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	// CategoryID and CategoryPath place the category in the taxonomy; the
+	// path lists category IDs from the root down
+	CategoryID   string     `json:"categoryId,omitempty"`
+	CategoryPath []string   `json:"categoryPath,omitempty"`
+	Description  string     `json:"description"`
+	Website      string     `json:"website"`
+	Confidence   int        `json:"confidence"`
+	Evidence     []Evidence `json:"evidence"`
+	// Extracted holds the distinct values of every named capture group
+	// matched across the evidence, such as tenant IDs or verification tokens
+	Extracted map[string][]string `json:"extracted,omitempty"`
+	// Inferred is set when the technology wasn't matched itself but is
+	// implied by another detection
+	Inferred bool `json:"inferred,omitempty"`
+	// ImpliedBy names the detections that imply this technology
+	ImpliedBy []string `json:"impliedBy,omitempty"`
+	// Baseline is expected or unexpected when results are compared with a
+	// baseline
+	Baseline string `json:"baseline,omitempty"`
+}
+
+// UnmarshalJSON also reads detections saved before evidence was a list of
+// records, when it was the matched value next to the record type
+func (t *DetectedTechnology) UnmarshalJSON(data []byte) error {
+	type detectedTechnology DetectedTechnology
+	var decoded struct {
+		detectedTechnology
+		Evidence   json.RawMessage `json:"evidence"`
+		RecordType string          `json:"recordType"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*t = DetectedTechnology(decoded.detectedTechnology)
+
+	if len(decoded.Evidence) == 0 || string(decoded.Evidence) == "null" {
+		return nil
+	}
+	if decoded.Evidence[0] != '"' {
+		return json.Unmarshal(decoded.Evidence, &t.Evidence)
+	}
+	var value string
+	if err := json.Unmarshal(decoded.Evidence, &value); err != nil {
+		return fmt.Errorf("error parsing evidence of %s: %v", t.Name, err)
+	}
+	t.Evidence = []Evidence{{RecordType: decoded.RecordType, Value: value}}
+	return nil
+}
+
+// Evidence is a DNS record that matched one of a signature's patterns
+type Evidence struct {
+	RecordType string `json:"recordType"`
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	Pattern    string `json:"pattern"`
+	Weight     int    `json:"weight"`
+	// Captures holds the named capture groups of the matching pattern
+	Captures map[string]string `json:"captures,omitempty"`
 }
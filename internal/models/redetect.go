@@ -0,0 +1,25 @@
+package models
+
+// DetectionDiff lists how the detections for a saved result changed when its
+// records were run through the signatures again
+type DetectionDiff struct {
+	Domain string `json:"domain"`
+	// Source is the file the result was read from
+	Source  string             `json:"source,omitempty"`
+	Added   []string           `json:"added,omitempty"`
+	Removed []string           `json:"removed,omitempty"`
+	Changed []ConfidenceChange `json:"changed,omitempty"`
+}
+
+// ConfidenceChange is a technology detected both times with a different
+// confidence
+type ConfidenceChange struct {
+	Name   string `json:"name"`
+	Before int    `json:"before"`
+	After  int    `json:"after"`
+}
+
+// Empty reports whether the detections are unchanged
+func (d DetectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
@@ -3,6 +3,19 @@ package models
 // Result is the final output structure
 type Result struct {
 	Domain               string               `json:"domain"`
+	Input                string               `json:"input,omitempty"`
 	DetectedTechnologies []DetectedTechnology `json:"detectedTechnologies"`
+	SPF                  *SPFResult           `json:"spf,omitempty"`
+	DMARC                *DMARCResult         `json:"dmarc,omitempty"`
+	MTASTS               *MTASTSResult        `json:"mtaSts,omitempty"`
+	TLSRPT               *TLSRPTResult        `json:"tlsRpt,omitempty"`
+	BIMI                 *BIMIResult          `json:"bimi,omitempty"`
+	ServiceBindings      []ServiceBinding     `json:"serviceBindings,omitempty"`
+	CAA                  *CAAResult           `json:"caa,omitempty"`
+	Nameservers          []Nameserver         `json:"nameservers,omitempty"`
+	Delegation           *DelegationResult    `json:"delegation,omitempty"`
+	Findings             []Finding            `json:"findings,omitempty"`
+	Baseline             *BaselineResult      `json:"baseline,omitempty"`
+	Trace                []ResolutionTrace    `json:"trace,omitempty"`
 	AllRecords           []DNSResponse        `json:"allRecords,omitempty"`
 }
@@ -1,13 +1,238 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // Signature represents a technology signature with regex patterns
 type Signature struct {
-	Name        string   `json:"name"`
-	Category    string   `json:"category"`
-	Description string   `json:"description"`
-	RecordTypes []string `json:"recordTypes"`
-	Patterns    []string `json:"patterns"`
-	Website     string   `json:"website"`
+	Name        string     `json:"name"`
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	RecordTypes []string   `json:"recordTypes"`
+	Patterns    []Pattern  `json:"patterns"`
+	Condition   *Condition `json:"condition,omitempty"`
+	Website     string     `json:"website"`
+	// Implies names technologies that come with this one and are reported
+	// as inferred whenever it is detected
+	Implies []string `json:"implies,omitempty"`
+	// Excludes names technologies that can't be in use alongside this one.
+	// When both are detected, the one with the lower confidence is dropped.
+	Excludes []string `json:"excludes,omitempty"`
+
+	// CategoryID and CategoryPath are filled in from the category taxonomy
+	CategoryID   string   `json:"-"`
+	CategoryPath []string `json:"-"`
+}
+
+// Condition is a boolean expression over all records of a domain. A
+// signature with a condition is only detected when the condition holds (and,
+// if it also has patterns, one of them matched). Exactly one of All, Any, Not
+// or Match is set.
+type Condition struct {
+	// All holds when every sub-condition holds
+	All []Condition `json:"all,omitempty"`
+	// Any holds when at least Min (default 1) sub-conditions hold
+	Any []Condition `json:"any,omitempty"`
+	Min int         `json:"min,omitempty"`
+	// Not holds when its sub-condition doesn't
+	Not *Condition `json:"not,omitempty"`
+
+	// Match holds when at least MinCount (default 1) records of one of
+	// RecordTypes (default: the signature's) match the pattern
+	Match       *Pattern `json:"match,omitempty"`
+	RecordTypes []string `json:"recordTypes,omitempty"`
+	MinCount    int      `json:"minCount,omitempty"`
+}
+
+// Fields of a record a pattern can match
+const (
+	FieldValue = "value"
+	FieldName  = "name"
+)
+
+// Kinds of pattern. All but regex compare literal text, so they need no
+// escaping and are matched through lookup tables.
+const (
+	PatternRegex    = "regex"
+	PatternExact    = "exact"
+	PatternPrefix   = "prefix"
+	PatternSuffix   = "suffix"
+	PatternContains = "contains"
+	PatternCIDR     = "cidr"
+	// PatternNumber compares a value, or one token of it, as an unsigned
+	// integer
+	PatternNumber = "number"
+	// PatternTTL is a pattern with only a TTL range
+	PatternTTL = "ttl"
+)
+
+// Pattern is one way a signature can match a record. In the signature file
+// it is either a plain regex string or an object with one pattern kind, such
+// as {"suffix": ".linode.com"} or {"regex": "...", "weight": 90}.
+type Pattern struct {
+	Regex string `json:"regex,omitempty"`
+	// Exact, Prefix and Contains compare text case-sensitively unless
+	// IgnoreCase is set; on owner names they always ignore case
+	Exact    string `json:"exact,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Contains string `json:"contains,omitempty"`
+	// Suffix matches whole trailing labels of a host name, ignoring case:
+	// ".linode.com" and "linode.com" both match "ns1.linode.com." but not
+	// "notlinode.com."
+	Suffix string `json:"suffix,omitempty"`
+	// CIDR matches address records inside a network such as "192.0.2.0/24"
+	CIDR       string `json:"cidr,omitempty"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty"`
+	// Number matches values that are an unsigned integer in the range
+	Number *Range `json:"number,omitempty"`
+	// Token restricts matching to the Nth space-separated part of the value,
+	// counting from 1, such as 6 for the expire time of an SOA record
+	Token int `json:"token,omitempty"`
+
+	// TTL restricts the pattern to records with a TTL in the range. On its
+	// own it matches every record in the range.
+	TTL *Range `json:"ttl,omitempty"`
+
+	// Weight is how certain a match alone makes the detection, from 1 to
+	// 100. Zero means the default weight for the record type.
+	Weight int `json:"weight,omitempty"`
+	// Field is the part of the record matched: its value (the default) or
+	// its owner name
+	Field string `json:"field,omitempty"`
+}
+
+// Range is an inclusive range of TTLs or numbers. A zero Max means no upper
+// bound.
+type Range struct {
+	Min uint32 `json:"min,omitempty"`
+	Max uint32 `json:"max,omitempty"`
+}
+
+// Contains reports whether n is inside the range
+func (r Range) Contains(n uint32) bool {
+	return n >= r.Min && (r.Max == 0 || n <= r.Max)
+}
+
+// String formats the range as min-max, leaving out an open upper bound
+func (r Range) String() string {
+	if r.Max == 0 {
+		return fmt.Sprintf("%d-", r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// Kind returns the kind of the pattern and its text
+func (p Pattern) Kind() (string, string) {
+	for _, kind := range []struct{ name, text string }{
+		{PatternRegex, p.Regex},
+		{PatternExact, p.Exact},
+		{PatternPrefix, p.Prefix},
+		{PatternSuffix, p.Suffix},
+		{PatternContains, p.Contains},
+		{PatternCIDR, p.CIDR},
+	} {
+		if kind.text != "" {
+			return kind.name, kind.text
+		}
+	}
+	if p.Number != nil {
+		return PatternNumber, p.Number.String()
+	}
+	if p.TTL != nil {
+		return PatternTTL, ""
+	}
+	return "", ""
+}
+
+// String describes the pattern: a regex as written, other kinds as kind:text
+func (p Pattern) String() string {
+	kind, text := p.Kind()
+	description := text
+	switch kind {
+	case PatternRegex:
+	case PatternTTL:
+		description = ""
+	default:
+		description = kind + ":" + text
+	}
+
+	if p.Token > 0 {
+		description += fmt.Sprintf(" token:%d", p.Token)
+	}
+	if p.TTL != nil {
+		if description != "" {
+			description += " "
+		}
+		description += "ttl:" + p.TTL.String()
+	}
+	return description
+}
+
+// Validate checks that the pattern sets exactly one kind and that its
+// options are in range
+func (p Pattern) Validate() error {
+	kinds := 0
+	for _, text := range []string{p.Regex, p.Exact, p.Prefix, p.Suffix, p.Contains, p.CIDR} {
+		if text != "" {
+			kinds++
+		}
+	}
+	if p.Number != nil {
+		kinds++
+	}
+	if kinds > 1 {
+		return fmt.Errorf("pattern %q sets more than one of regex, exact, prefix, suffix, contains, cidr and number", p.String())
+	}
+	if kinds == 0 && p.TTL == nil {
+		return fmt.Errorf("pattern sets none of regex, exact, prefix, suffix, contains, cidr, number and ttl")
+	}
+	if p.TTL != nil && p.TTL.Max > 0 && p.TTL.Max < p.TTL.Min {
+		return fmt.Errorf("pattern %q: ttl max %d is below min %d", p.String(), p.TTL.Max, p.TTL.Min)
+	}
+	if p.Number != nil && p.Number.Max > 0 && p.Number.Max < p.Number.Min {
+		return fmt.Errorf("pattern %q: number max %d is below min %d", p.String(), p.Number.Max, p.Number.Min)
+	}
+	if p.Token < 0 {
+		return fmt.Errorf("pattern %q: token %d is below 1", p.String(), p.Token)
+	}
+	if p.Weight < 0 || p.Weight > 100 {
+		return fmt.Errorf("pattern %q: weight %d is outside 0-100", p.String(), p.Weight)
+	}
+	if p.Field != "" && p.Field != FieldValue && p.Field != FieldName {
+		return fmt.Errorf("pattern %q: unknown field %q", p.String(), p.Field)
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts both the string and the object form of a pattern
+func (p *Pattern) UnmarshalJSON(data []byte) error {
+	var regex string
+	if err := json.Unmarshal(data, &regex); err == nil {
+		*p = Pattern{Regex: regex}
+		return nil
+	}
+
+	type pattern Pattern
+	var object pattern
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fmt.Errorf("pattern must be a string or an object: %v", err)
+	}
+	if err := Pattern(object).Validate(); err != nil {
+		return err
+	}
+	*p = Pattern(object)
+	return nil
+}
+
+// MarshalJSON writes plain regex patterns in the short string form
+func (p Pattern) MarshalJSON() ([]byte, error) {
+	if (p == Pattern{Regex: p.Regex}) {
+		return json.Marshal(p.Regex)
+	}
+	type pattern Pattern
+	return json.Marshal(pattern(p))
 }
 
 // SignatureFile contains all technology signatures
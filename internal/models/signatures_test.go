@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPatternJSON(t *testing.T) {
+	data := `["^v=spf1 ", {"regex": "MS=ms\\d+", "weight": 40}, {"regex": "^_amazonses\\.", "field": "name"}]`
+
+	var patterns []Pattern
+	if err := json.Unmarshal([]byte(data), &patterns); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Pattern{{Regex: "^v=spf1 "}, {Regex: "MS=ms\\d+", Weight: 40}, {Regex: "^_amazonses\\.", Field: FieldName}}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, patterns)
+	}
+
+	encoded, err := json.Marshal(patterns)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(encoded) != `["^v=spf1 ",{"regex":"MS=ms\\d+","weight":40},{"regex":"^_amazonses\\.","field":"name"}]` {
+		t.Errorf("Unexpected encoding %s", encoded)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"regex": "x", "weight": 150}]`), &patterns); err == nil {
+		t.Errorf("Expected an out of range weight to be rejected")
+	}
+	if err := json.Unmarshal([]byte(`[{"regex": "x", "field": "ttl"}]`), &patterns); err == nil {
+		t.Errorf("Expected an unknown field to be rejected")
+	}
+}
+
+func TestPatternKinds(t *testing.T) {
+	data := `[{"suffix": ".linode.com"}, {"cidr": "192.0.2.0/24", "ttl": {"max": 60}}, {"ttl": {"min": 3600}}, {"number": {"max": 604799}, "token": 6}]`
+
+	var patterns []Pattern
+	if err := json.Unmarshal([]byte(data), &patterns); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var descriptions []string
+	for _, pattern := range patterns {
+		descriptions = append(descriptions, pattern.String())
+	}
+	expected := []string{"suffix:.linode.com", "cidr:192.0.2.0/24 ttl:0-60", "ttl:3600-", "number:0-604799 token:6"}
+	if !reflect.DeepEqual(descriptions, expected) {
+		t.Errorf("Expected %v, got %v", expected, descriptions)
+	}
+
+	encoded, err := json.Marshal(patterns[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(encoded) != `{"suffix":".linode.com"}` {
+		t.Errorf("Unexpected encoding %s", encoded)
+	}
+
+	for _, invalid := range []string{
+		`[{"exact": "a", "suffix": "b"}]`,
+		`[{"weight": 10}]`,
+		`[{"ttl": {"min": 60, "max": 30}}]`,
+		`[{"number": {"min": 60, "max": 30}}]`,
+		`[{"exact": "a", "number": {"max": 1}}]`,
+		`[{"exact": "a", "token": -1}]`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &patterns); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// ServiceBinding is a parsed SVCB or HTTPS record (RFC 9460)
+type ServiceBinding struct {
+	Name          string            `json:"name"`
+	RecordType    string            `json:"recordType"`
+	Priority      uint16            `json:"priority"`
+	Target        string            `json:"target"`
+	AliasMode     bool              `json:"aliasMode"`
+	ALPN          []string          `json:"alpn,omitempty"`
+	NoDefaultALPN bool              `json:"noDefaultAlpn,omitempty"`
+	Port          uint16            `json:"port,omitempty"`
+	IPv4Hints     []string          `json:"ipv4Hints,omitempty"`
+	IPv6Hints     []string          `json:"ipv6Hints,omitempty"`
+	ECHConfig     string            `json:"echConfig,omitempty"`
+	Mandatory     []string          `json:"mandatory,omitempty"`
+	OtherParams   map[string]string `json:"otherParams,omitempty"`
+	HTTP3         bool              `json:"http3"`
+	ECH           bool              `json:"ech"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// ResolutionTrace records the queries sent to resolve one name iteratively
+type ResolutionTrace struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Steps []TraceStep `json:"steps"`
+	Error string      `json:"error,omitempty"`
+}
+
+// TraceStep is one query sent to one server during iterative resolution.
+// A step either ends in a referral to a child zone, an answer or an error.
+type TraceStep struct {
+	Zone          string   `json:"zone"`
+	Server        string   `json:"server"`
+	Name          string   `json:"name"`
+	Rcode         string   `json:"rcode,omitempty"`
+	Authoritative bool     `json:"authoritative,omitempty"`
+	Referral      string   `json:"referral,omitempty"`
+	Nameservers   []string `json:"nameservers,omitempty"`
+	Answers       []string `json:"answers,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
@@ -0,0 +1,385 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// File is a policy as written in YAML or JSON
+type File struct {
+	Assertions []Assertion `json:"assertions" yaml:"assertions"`
+}
+
+// Assertion checks the values found at a path in a result. Paths use the
+// field names of the JSON output, step into arrays automatically and can
+// filter array elements by a field, as in allRecords[recordType=MX].value.
+// Every operator that is set must hold.
+type Assertion struct {
+	ID          string `json:"id" yaml:"id"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Path        string `json:"path" yaml:"path"`
+
+	// Equals requires every value to equal it
+	Equals interface{} `json:"equals,omitempty" yaml:"equals,omitempty"`
+	// OneOf requires every value to be in the list
+	OneOf []interface{} `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	// NoneOf forbids the listed values
+	NoneOf []interface{} `json:"noneOf,omitempty" yaml:"noneOf,omitempty"`
+	// Contains requires one of the values to equal it
+	Contains interface{} `json:"contains,omitempty" yaml:"contains,omitempty"`
+	// Matches requires every value to match the regular expression
+	Matches string `json:"matches,omitempty" yaml:"matches,omitempty"`
+	// Exists requires the path to have values (true) or none (false)
+	Exists *bool `json:"exists,omitempty" yaml:"exists,omitempty"`
+}
+
+// Violation is an assertion that didn't hold for a domain
+type Violation struct {
+	Domain      string `json:"domain"`
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Message     string `json:"message"`
+}
+
+// Policy is a validated set of assertions
+type Policy struct {
+	assertions []compiledAssertion
+}
+
+// compiledAssertion is an assertion with its path parsed and its values
+// turned into strings
+type compiledAssertion struct {
+	assertion Assertion
+	path      []step
+	equals    *string
+	oneOf     []string
+	noneOf    []string
+	contains  *string
+	matches   *regexp.Regexp
+}
+
+// step is one field of a path with an optional element filter
+type step struct {
+	field       string
+	filterKey   string
+	filterValue string
+}
+
+// LoadFile reads and validates a policy file. JSON is read as YAML, which
+// it is a subset of.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file %s: %v", path, err)
+	}
+
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing policy file %s: %v", path, err)
+	}
+	if len(file.Assertions) == 0 {
+		return nil, fmt.Errorf("no assertions found in policy file: %s", path)
+	}
+
+	policy, err := New(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %v", path, err)
+	}
+	return policy, nil
+}
+
+// New checks that every assertion has a unique ID, a valid path and at least
+// one operator
+func New(file File) (*Policy, error) {
+	policy := &Policy{}
+	seen := make(map[string]bool)
+
+	for _, assertion := range file.Assertions {
+		if assertion.ID == "" {
+			return nil, fmt.Errorf("assertion on %q needs an id", assertion.Path)
+		}
+		if seen[assertion.ID] {
+			return nil, fmt.Errorf("duplicate assertion id %q", assertion.ID)
+		}
+		seen[assertion.ID] = true
+
+		compiled, err := compileAssertion(assertion)
+		if err != nil {
+			return nil, fmt.Errorf("assertion %s: %v", assertion.ID, err)
+		}
+		policy.assertions = append(policy.assertions, compiled)
+	}
+	return policy, nil
+}
+
+// Len returns the number of assertions
+func (p *Policy) Len() int {
+	return len(p.assertions)
+}
+
+// compileAssertion parses the path and normalizes the operator values
+func compileAssertion(assertion Assertion) (compiledAssertion, error) {
+	path, err := parsePath(assertion.Path)
+	if err != nil {
+		return compiledAssertion{}, err
+	}
+
+	compiled := compiledAssertion{assertion: assertion, path: path}
+	if assertion.Equals != nil {
+		equals := valueString(assertion.Equals)
+		compiled.equals = &equals
+	}
+	if assertion.Contains != nil {
+		contains := valueString(assertion.Contains)
+		compiled.contains = &contains
+	}
+	for _, value := range assertion.OneOf {
+		compiled.oneOf = append(compiled.oneOf, valueString(value))
+	}
+	for _, value := range assertion.NoneOf {
+		compiled.noneOf = append(compiled.noneOf, valueString(value))
+	}
+	if assertion.Matches != "" {
+		re, err := regexp.Compile(assertion.Matches)
+		if err != nil {
+			return compiledAssertion{}, err
+		}
+		compiled.matches = re
+	}
+
+	if compiled.equals == nil && compiled.contains == nil && compiled.matches == nil &&
+		len(compiled.oneOf) == 0 && len(compiled.noneOf) == 0 && assertion.Exists == nil {
+		return compiledAssertion{}, fmt.Errorf("sets none of equals, oneOf, noneOf, contains, matches and exists")
+	}
+	return compiled, nil
+}
+
+// parsePath splits a path such as allRecords[recordType=MX].value into its
+// steps. Filter values may contain dots.
+func parsePath(path string) ([]step, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("missing path")
+	}
+
+	var steps []step
+	for rest := path; rest != ""; {
+		var current step
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		current.field = rest[:end]
+		rest = rest[end:]
+
+		if strings.HasPrefix(rest, "[") {
+			closing := strings.Index(rest, "]")
+			if closing < 0 {
+				return nil, fmt.Errorf("path %q: unclosed [", path)
+			}
+			key, value, ok := strings.Cut(rest[1:closing], "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("path %q: filter must be [field=value]", path)
+			}
+			current.filterKey, current.filterValue = key, value
+			rest = rest[closing+1:]
+		}
+
+		if current.field == "" {
+			return nil, fmt.Errorf("path %q: empty field", path)
+		}
+		steps = append(steps, current)
+
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return nil, fmt.Errorf("path %q: expected . after %s", path, current.field)
+			}
+			rest = rest[1:]
+		}
+	}
+	return steps, nil
+}
+
+// Check evaluates every assertion against a result
+func (p *Policy) Check(result models.Result) []Violation {
+	// Assertions address the JSON output, so evaluate against that form
+	data, err := json.Marshal(result)
+	if err != nil {
+		return []Violation{{Domain: result.Domain, ID: "policy", Message: fmt.Sprintf("error encoding result: %v", err)}}
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return []Violation{{Domain: result.Domain, ID: "policy", Message: fmt.Sprintf("error decoding result: %v", err)}}
+	}
+
+	var violations []Violation
+	for _, compiled := range p.assertions {
+		values := collect([]interface{}{document}, compiled.path)
+		for _, message := range compiled.check(values) {
+			violations = append(violations, Violation{
+				Domain:      result.Domain,
+				ID:          compiled.assertion.ID,
+				Description: compiled.assertion.Description,
+				Message:     message,
+			})
+		}
+	}
+	return violations
+}
+
+// check returns a message for every operator that doesn't hold
+func (a compiledAssertion) check(values []string) []string {
+	path := a.assertion.Path
+	var messages []string
+
+	if a.assertion.Exists != nil {
+		if *a.assertion.Exists && len(values) == 0 {
+			messages = append(messages, fmt.Sprintf("%s is missing", path))
+		}
+		if !*a.assertion.Exists && len(values) > 0 {
+			messages = append(messages, fmt.Sprintf("%s is present: %q", path, values[0]))
+		}
+	}
+
+	// Operators on the values need something to look at
+	if len(values) == 0 {
+		if a.equals != nil || a.contains != nil || a.matches != nil || len(a.oneOf) > 0 {
+			if a.assertion.Exists == nil {
+				messages = append(messages, fmt.Sprintf("%s has no value", path))
+			}
+		}
+		return messages
+	}
+
+	if a.equals != nil {
+		for _, value := range values {
+			if value != *a.equals {
+				messages = append(messages, fmt.Sprintf("%s is %q, expected %q", path, value, *a.equals))
+				break
+			}
+		}
+	}
+	if len(a.oneOf) > 0 {
+		for _, value := range values {
+			if !contains(a.oneOf, value) {
+				messages = append(messages, fmt.Sprintf("%s has %q, which is not one of %q", path, value, a.oneOf))
+				break
+			}
+		}
+	}
+	for _, value := range values {
+		if contains(a.noneOf, value) {
+			messages = append(messages, fmt.Sprintf("%s has %q, which is not allowed", path, value))
+			break
+		}
+	}
+	if a.contains != nil && !contains(values, *a.contains) {
+		messages = append(messages, fmt.Sprintf("%s does not contain %q", path, *a.contains))
+	}
+	if a.matches != nil {
+		for _, value := range values {
+			if !a.matches.MatchString(value) {
+				messages = append(messages, fmt.Sprintf("%s has %q, which does not match %s", path, value, a.matches))
+				break
+			}
+		}
+	}
+	return messages
+}
+
+// collect follows the path from the given nodes and returns the values at
+// its end as strings. Arrays are flattened at every step; missing fields,
+// nulls and empty strings produce no value.
+func collect(nodes []interface{}, path []step) []string {
+	for _, current := range path {
+		var next []interface{}
+		for _, node := range flatten(nodes) {
+			object, ok := node.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, child := range flatten([]interface{}{object[current.field]}) {
+				if current.filterKey != "" && !matchesFilter(child, current) {
+					continue
+				}
+				next = append(next, child)
+			}
+		}
+		nodes = next
+	}
+
+	var values []string
+	for _, node := range flatten(nodes) {
+		if value := valueString(node); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// flatten replaces arrays with their elements
+func flatten(nodes []interface{}) []interface{} {
+	var flat []interface{}
+	for _, node := range nodes {
+		if array, ok := node.([]interface{}); ok {
+			flat = append(flat, flatten(array)...)
+		} else if node != nil {
+			flat = append(flat, node)
+		}
+	}
+	return flat
+}
+
+// matchesFilter reports whether an element has the filter's field value
+func matchesFilter(node interface{}, current step) bool {
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, value := range flatten([]interface{}{object[current.filterKey]}) {
+		if valueString(value) == current.filterValue {
+			return true
+		}
+	}
+	return false
+}
+
+// valueString renders a scalar the way it is written in a policy: numbers
+// without a trailing .0, booleans as true and false. Objects become JSON.
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+const testPolicy = `
+assertions:
+  - id: dmarc-reject
+    description: DMARC policy must be reject
+    path: dmarc.policy
+    equals: reject
+  - id: approved-mx
+    path: allRecords[recordType=MX].value
+    matches: '^\d+ mx[12]\.example\.net\.$'
+  - id: no-marketing
+    path: detectedTechnologies.categoryPath
+    noneOf: [marketing]
+  - id: letsencrypt-only
+    path: caa.authorizedCas
+    oneOf: [letsencrypt.org]
+  - id: full-enforcement
+    path: dmarc.percentage
+    equals: 100
+  - id: no-high-findings
+    path: findings[severity=high].id
+    exists: false
+`
+
+func testResult() models.Result {
+	return models.Result{
+		Domain: "example.com",
+		DetectedTechnologies: []models.DetectedTechnology{
+			{Name: "Google Workspace", CategoryPath: []string{"email", "email-hosting"}},
+		},
+		DMARC: &models.DMARCResult{Policy: "reject", Percentage: 100},
+		CAA:   &models.CAAResult{AuthorizedCAs: []string{"letsencrypt.org"}},
+		AllRecords: []models.DNSResponse{
+			{Domain: "example.com.", RecordType: "MX", Value: "10 mx1.example.net."},
+			{Domain: "example.com.", RecordType: "MX", Value: "20 mx2.example.net."},
+			{Domain: "example.com.", RecordType: "TXT", Value: "v=spf1 -all"},
+		},
+		Findings: []models.Finding{{ID: "caa-missing", Severity: models.SeverityLow}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	policy, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		modify   func(*models.Result)
+		expected []string
+	}{
+		{"compliant", func(r *models.Result) {}, nil},
+		{"weak DMARC", func(r *models.Result) { r.DMARC.Policy = "none"; r.DMARC.Percentage = 50 }, []string{"dmarc-reject", "full-enforcement"}},
+		{"no DMARC", func(r *models.Result) { r.DMARC = nil }, []string{"dmarc-reject", "full-enforcement"}},
+		{"unapproved MX", func(r *models.Result) {
+			r.AllRecords = append(r.AllRecords, models.DNSResponse{RecordType: "MX", Value: "10 mx.other.net."})
+		}, []string{"approved-mx"}},
+		{"category in the path", func(r *models.Result) {
+			r.DetectedTechnologies = append(r.DetectedTechnologies, models.DetectedTechnology{Name: "HubSpot", CategoryPath: []string{"marketing", "marketing-automation"}})
+		}, []string{"no-marketing"}},
+		{"second CA", func(r *models.Result) { r.CAA.AuthorizedCAs = append(r.CAA.AuthorizedCAs, "digicert.com") }, []string{"letsencrypt-only"}},
+		{"high finding", func(r *models.Result) {
+			r.Findings = append(r.Findings, models.Finding{ID: "spf-pass-all", Severity: models.SeverityHigh})
+		}, []string{"no-high-findings"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testResult()
+			tc.modify(&result)
+
+			var ids []string
+			for _, violation := range policy.Check(result) {
+				ids = append(ids, violation.ID)
+				if violation.Domain != "example.com" || violation.Message == "" {
+					t.Errorf("Incomplete violation %+v", violation)
+				}
+			}
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, ids)
+			}
+		})
+	}
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	testCases := []struct {
+		name          string
+		assertion     Assertion
+		errorContains string
+	}{
+		{"missing id", Assertion{Path: "dmarc.policy", Equals: "reject"}, "needs an id"},
+		{"no operator", Assertion{ID: "a", Path: "dmarc.policy"}, "sets none of"},
+		{"empty path", Assertion{ID: "a", Equals: "reject"}, "missing path"},
+		{"unclosed filter", Assertion{ID: "a", Path: "allRecords[recordType=MX.value", Equals: "x"}, "unclosed"},
+		{"filter without value", Assertion{ID: "a", Path: "allRecords[recordType].value", Equals: "x"}, "[field=value]"},
+		{"bad regex", Assertion{ID: "a", Path: "dmarc.policy", Matches: "("}, "assertion a"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(File{Assertions: []Assertion{tc.assertion}})
+			if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+				t.Errorf("Expected error containing %q, got %v", tc.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := parsePath("allRecords[domain=_dmarc.example.com.].value")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []step{{field: "allRecords", filterKey: "domain", filterValue: "_dmarc.example.com."}, {field: "value"}}
+	if !reflect.DeepEqual(path, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, path)
+	}
+}
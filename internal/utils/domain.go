@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// domainProfile converts Unicode names to punycode and validates them the
+// way a resolver would, while still allowing underscore labels such as
+// _dmarc or _mta-sts
+var domainProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(false),
+)
+
+// NormalizeDomain turns user input into a bare, lowercase ASCII domain name.
+// It accepts URLs, email addresses, host:port pairs, trailing dots and
+// Unicode IDNs. With registrable set, the name is reduced to its registrable
+// domain (eTLD+1) using the embedded public suffix list.
+func NormalizeDomain(input string, registrable bool) (string, error) {
+	host := strings.TrimSpace(input)
+	if host == "" {
+		return "", fmt.Errorf("empty domain name")
+	}
+
+	// URLs, email addresses and host:port all carry the name in the host part
+	host = strings.TrimPrefix(host, "mailto:")
+	if !strings.Contains(host, "://") {
+		if local, rest, isEmail := strings.Cut(host, "@"); isEmail && (local == "" || strings.Contains(rest, "@")) {
+			return "", fmt.Errorf("malformed email address %q", input)
+		}
+		host = "//" + host
+	}
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse %q: %v", input, err)
+	}
+	host = parsed.Hostname()
+	if host == "" {
+		return "", fmt.Errorf("no domain name in %q", input)
+	}
+
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%s is an IP address, not a domain name", host)
+	}
+
+	host = strings.TrimSuffix(host, ".")
+	ascii, err := domainProfile.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid domain name %q: %v", host, err)
+	}
+	// Only one trailing dot was removed, so any dot left at the end is an
+	// empty label too
+	if ascii == "" || strings.HasPrefix(ascii, ".") || strings.HasSuffix(ascii, ".") || strings.Contains(ascii, "..") {
+		return "", fmt.Errorf("invalid domain name %q: empty label", host)
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if err := checkLabel(label); err != nil {
+			return "", fmt.Errorf("invalid domain name %q: %v", host, err)
+		}
+	}
+	if !strings.Contains(ascii, ".") {
+		return "", fmt.Errorf("invalid domain name %q: a domain needs at least two labels", host)
+	}
+
+	if registrable {
+		apex, err := publicsuffix.EffectiveTLDPlusOne(ascii)
+		if err != nil {
+			return "", fmt.Errorf("cannot find the registrable domain of %s: %v", ascii, err)
+		}
+		return apex, nil
+	}
+
+	return ascii, nil
+}
+
+// checkLabel enforces the letters, digits and hyphens rule for a label of an
+// ASCII name; the IDNA profile already rejects leading and trailing hyphens.
+// Underscores are allowed too, for labels such as _dmarc.
+func checkLabel(label string) error {
+	for _, c := range label {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return fmt.Errorf("label %q contains %q", label, c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDomain(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		registrable   bool
+		expected      string
+		errorContains string
+	}{
+		{name: "Plain domain", input: "example.com", expected: "example.com"},
+		{name: "Uppercase with trailing dot", input: " EXAMPLE.COM. ", expected: "example.com"},
+		{name: "URL with path", input: "https://www.example.co.uk/login?next=/", expected: "www.example.co.uk"},
+		{name: "URL reduced to registrable domain", input: "https://www.example.co.uk/login", registrable: true, expected: "example.co.uk"},
+		{name: "Host and port", input: "mail.example.com:25", expected: "mail.example.com"},
+		{name: "Email address", input: "user@example.com", expected: "example.com"},
+		{name: "Mailto URI", input: "mailto:security@Example.org", expected: "example.org"},
+		{name: "Unicode IDN", input: "bücher.example", expected: "xn--bcher-kva.example"},
+		{name: "Underscore label", input: "_dmarc.example.com", expected: "_dmarc.example.com"},
+		{name: "Empty input", input: "  ", errorContains: "empty"},
+		{name: "IP address", input: "192.0.2.1", errorContains: "IP address"},
+		{name: "Single label", input: "localhost", errorContains: "two labels"},
+		{name: "Empty label", input: "example..com", errorContains: "invalid domain"},
+		{name: "Repeated trailing dots", input: "example.com..", errorContains: "empty label"},
+		{name: "Punctuation in a label", input: "ex!ample.com", errorContains: "invalid domain"},
+		{name: "Wildcard label", input: "*.example.com", errorContains: "invalid domain"},
+		{name: "Hyphen at the end of a label", input: "example-.com", errorContains: "invalid domain"},
+		{name: "Two at signs", input: "user@@example.com", errorContains: "malformed email"},
+		{name: "Empty mailbox", input: "@example.com", errorContains: "malformed email"},
+		{name: "Label too long", input: strings.Repeat("a", 64) + ".com", errorContains: "invalid domain"},
+		{name: "Public suffix only", input: "co.uk", registrable: true, errorContains: "registrable domain"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			domain, err := NormalizeDomain(tc.input, tc.registrable)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v (domain %q)", tc.errorContains, err, domain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if domain != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, domain)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+// ResultFile is a file of saved results and the form it was saved in
+type ResultFile struct {
+	Path string
+	// Combined is set for a results file written for a target list, which
+	// wraps the results with a timestamp and count
+	Combined  bool
+	Timestamp string
+	Results   []models.Result
+}
+
+// combinedResults is the layout of a combined results file
+type combinedResults struct {
+	Timestamp string          `json:"timestamp"`
+	Count     int             `json:"count"`
+	Results   []models.Result `json:"results"`
+}
+
+// LoadResults reads saved radar output. The path may be a single result, a
+// combined results file, the JSON printed for a target list (one result after
+// another) or a directory of such files.
+func LoadResults(path string) ([]models.Result, error) {
+	files, err := LoadResultFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Result
+	for _, file := range files {
+		results = append(results, file.Results...)
+	}
+	return results, nil
+}
+
+// LoadResultFiles reads saved radar output like LoadResults, keeping track of
+// the file each result came from. Directories are read in name order.
+func LoadResultFiles(path string) ([]ResultFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		file, err := loadResultsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []ResultFile{file}, nil
+	}
+
+	var paths []string
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(strings.ToLower(file), ".json") {
+			paths = append(paths, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading results directory %s: %v", path, err)
+	}
+	sort.Strings(paths)
+
+	var files []ResultFile
+	for _, file := range paths {
+		loaded, err := loadResultsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, loaded)
+	}
+	return files, nil
+}
+
+// loadResultsFile reads every result in one file
+func loadResultsFile(path string) (ResultFile, error) {
+	loaded := ResultFile{Path: path}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return loaded, fmt.Errorf("error opening results file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	documents := 0
+	decoder := json.NewDecoder(file)
+	for {
+		var document struct {
+			models.Result
+			combinedResults
+		}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("error parsing results file %s: %v", path, err)
+		}
+		documents++
+
+		switch {
+		case document.combinedResults.Results != nil:
+			loaded.Combined = documents == 1
+			loaded.Timestamp = document.Timestamp
+			loaded.Results = append(loaded.Results, document.combinedResults.Results...)
+		case document.Domain != "":
+			loaded.Combined = false
+			loaded.Results = append(loaded.Results, document.Result)
+		default:
+			return loaded, fmt.Errorf("error parsing results file %s: not a radar result", path)
+		}
+	}
+	return loaded, nil
+}
+
+// SaveResultFile writes results in the form they were loaded in: wrapped in
+// a combined results file, or one result after another
+func SaveResultFile(file ResultFile, path string) error {
+	var content string
+	if file.Combined {
+		output, err := FormatJSON(combinedResults{Timestamp: file.Timestamp, Count: len(file.Results), Results: file.Results})
+		if err != nil {
+			return fmt.Errorf("error encoding results: %v", err)
+		}
+		content = output
+	} else {
+		var outputs []string
+		for _, result := range file.Results {
+			output, err := FormatJSON(result)
+			if err != nil {
+				return fmt.Errorf("error encoding results for %s: %v", result.Domain, err)
+			}
+			outputs = append(outputs, output)
+		}
+		content = strings.Join(outputs, "\n")
+	}
+	return SaveToFile(content, path)
+}
+
+// LoadBaseline reads a baseline file and normalizes its domain keys
+func LoadBaseline(path string) (*models.Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline file %s: %v", path, err)
+	}
+
+	var baseline models.Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("error parsing baseline file %s: %v", path, err)
+	}
+
+	domains := make(map[string]models.BaselineEntry, len(baseline.Domains))
+	for domain, entry := range baseline.Domains {
+		key := models.BaselineKey(domain)
+		if _, exists := domains[key]; exists {
+			return nil, fmt.Errorf("baseline file %s lists %s twice", path, key)
+		}
+		domains[key] = entry
+	}
+	baseline.Domains = domains
+	return &baseline, nil
+}
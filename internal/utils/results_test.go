@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Elite-Security-Systems/radar/internal/models"
+)
+
+func TestLoadResults(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"single.json":   `{"domain": "a.example", "detectedTechnologies": [{"name": "Mimecast"}]}`,
+		"combined.json": `{"timestamp": "2025-01-01T00:00:00Z", "count": 2, "results": [{"domain": "b.example"}, {"domain": "c.example"}]}`,
+		"stdout.json":   "{\"domain\": \"d.example\"}\n{\"domain\": \"e.example\"}\n",
+		"notes.txt":     "not a result",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	results, err := LoadResults(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var domains []string
+	for _, result := range results {
+		domains = append(domains, result.Domain)
+	}
+	if expected := []string{"b.example", "c.example", "a.example", "d.example", "e.example"}; !reflect.DeepEqual(domains, expected) {
+		t.Errorf("Expected %v, got %v", expected, domains)
+	}
+	if results[2].DetectedTechnologies[0].Name != "Mimecast" {
+		t.Errorf("Expected the detections to be loaded, got %+v", results[2])
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"signatures": []}`), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := LoadResults(invalid); err == nil {
+		t.Errorf("Expected a file without results to be rejected")
+	}
+}
+
+func TestSaveResultFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"combined.json": `{"timestamp": "2025-01-01T00:00:00Z", "count": 2, "results": [{"domain": "b.example"}, {"domain": "c.example"}]}`,
+		"stdout.json":   "{\"domain\": \"d.example\"}\n{\"domain\": \"e.example\"}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		loaded, err := LoadResultFiles(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		saved := filepath.Join(dir, "saved", name)
+		if err := SaveResultFile(loaded[0], saved); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		reloaded, err := LoadResultFiles(saved)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		loaded[0].Path, reloaded[0].Path = "", ""
+		if !reflect.DeepEqual(reloaded[0], loaded[0]) {
+			t.Errorf("%s: expected %+v, got %+v", name, loaded[0], reloaded[0])
+		}
+	}
+}
+
+func TestLoadBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(`{"domains": {"Example.COM.": {"technologies": ["Mimecast"]}, "*": {"categories": ["dns"]}}}`), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entry := baseline.Entry("example.com")
+	if !reflect.DeepEqual(entry.Technologies, []string{"Mimecast"}) || !reflect.DeepEqual(entry.Categories, []string{"dns"}) {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"domains": {"example.com": {}, "EXAMPLE.com": {}}}`), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := LoadBaseline(path); err == nil {
+		t.Errorf("Expected a domain listed twice to be rejected")
+	}
+}
+
+func TestLoadLegacyResults(t *testing.T) {
+	// Saved before evidence became a list of records, when each detection had
+	// a single evidence value and a record type
+	results, err := LoadResults("testdata/legacy-result.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || len(results[0].AllRecords) != 2 {
+		t.Fatalf("Expected one result with its records, got %+v", results)
+	}
+
+	expected := []models.Evidence{{RecordType: "MX", Value: "1 aspmx.l.google.com."}}
+	if tech := results[0].DetectedTechnologies[0]; tech.Name != "Google Workspace" || !reflect.DeepEqual(tech.Evidence, expected) {
+		t.Errorf("Expected the old evidence as %+v, got %+v", expected, tech)
+	}
+
+	// An old file doesn't stop a directory from loading
+	dir := t.TempDir()
+	legacy, err := os.ReadFile("testdata/legacy-result.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, content := range map[string]string{"old.json": string(legacy), "new.json": `{"domain": "b.example"}`} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if results, err := LoadResults(dir); err != nil || len(results) != 2 {
+		t.Errorf("Expected both results, got %d (%v)", len(results), err)
+	}
+}
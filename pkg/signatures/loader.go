@@ -21,6 +21,16 @@ const (
 	DefaultCachePath = "/tmp/radar-sigs.json"
 	// MaxCacheAge is the maximum age of the cached signatures file before redownloading
 	MaxCacheAge = 7 * 24 * time.Hour // 1 week
+
+	// DefaultCategoriesURL is the URL to download the category taxonomy from
+	DefaultCategoriesURL = "https://raw.githubusercontent.com/Elite-Security-Systems/radar/refs/heads/main/data/categories.json"
+	// DefaultCategoriesCachePath is the path to store the downloaded taxonomy
+	DefaultCategoriesCachePath = "/tmp/radar-categories.json"
+
+	// DefaultRulesURL is the URL to download the misconfiguration rules from
+	DefaultRulesURL = "https://raw.githubusercontent.com/Elite-Security-Systems/radar/refs/heads/main/data/rules.json"
+	// DefaultRulesCachePath is the path to store the downloaded rules
+	DefaultRulesCachePath = "/tmp/radar-rules.json"
 )
 
 // LoadFromFile loads signatures from a JSON file
@@ -111,6 +121,94 @@ func GetOrDownloadSignatures() (string, error) {
 	return DefaultCachePath, nil
 }
 
+// LoadCategoriesFromFile loads and validates the category taxonomy. The
+// default path is downloaded and cached like the signatures file.
+func LoadCategoriesFromFile(path string) (*models.Taxonomy, error) {
+	if path == "data/categories.json" {
+		if resolvedPath, err := GetOrDownloadCategories(); err == nil {
+			path = resolvedPath
+		}
+	}
+
+	resolvedPath, err := utils.FindFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(resolvedPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading categories file %s: %v", resolvedPath, err)
+	}
+
+	var categories models.CategoryFile
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("error parsing categories file %s: %v", resolvedPath, err)
+	}
+	if len(categories.Categories) == 0 {
+		return nil, fmt.Errorf("no categories found in file: %s", resolvedPath)
+	}
+
+	taxonomy, err := models.NewTaxonomy(categories)
+	if err != nil {
+		return nil, fmt.Errorf("invalid categories file %s: %v", resolvedPath, err)
+	}
+	return taxonomy, nil
+}
+
+// GetOrDownloadCategories returns the path to a cached copy of the category
+// taxonomy, downloading it when missing or older than MaxCacheAge
+func GetOrDownloadCategories() (string, error) {
+	info, err := os.Stat(DefaultCategoriesCachePath)
+	if err != nil || time.Since(info.ModTime()) > MaxCacheAge {
+		if err := DownloadSignatures(DefaultCategoriesURL, DefaultCategoriesCachePath); err != nil {
+			return "", fmt.Errorf("failed to download categories: %v", err)
+		}
+	}
+	return DefaultCategoriesCachePath, nil
+}
+
+// LoadRulesFromFile loads the misconfiguration rules. The default path is
+// downloaded and cached like the signatures file.
+func LoadRulesFromFile(path string) (models.RuleFile, error) {
+	var rules models.RuleFile
+
+	if path == "data/rules.json" {
+		if resolvedPath, err := GetOrDownloadRules(); err == nil {
+			path = resolvedPath
+		}
+	}
+
+	resolvedPath, err := utils.FindFile(path)
+	if err != nil {
+		return rules, err
+	}
+
+	data, err := ioutil.ReadFile(resolvedPath)
+	if err != nil {
+		return rules, fmt.Errorf("error reading rules file %s: %v", resolvedPath, err)
+	}
+
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("error parsing rules file %s: %v", resolvedPath, err)
+	}
+	if len(rules.Rules) == 0 {
+		return rules, fmt.Errorf("no rules found in file: %s", resolvedPath)
+	}
+	return rules, nil
+}
+
+// GetOrDownloadRules returns the path to a cached copy of the rules,
+// downloading them when missing or older than MaxCacheAge
+func GetOrDownloadRules() (string, error) {
+	info, err := os.Stat(DefaultRulesCachePath)
+	if err != nil || time.Since(info.ModTime()) > MaxCacheAge {
+		if err := DownloadSignatures(DefaultRulesURL, DefaultRulesCachePath); err != nil {
+			return "", fmt.Errorf("failed to download rules: %v", err)
+		}
+	}
+	return DefaultRulesCachePath, nil
+}
+
 // DownloadSignatures downloads signatures from the specified URL to the specified path
 func DownloadSignatures(url, path string) error {
 	// Create the HTTP request
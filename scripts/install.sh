@@ -103,6 +103,30 @@ elif command -v wget > /dev/null; then
     wget -O "$SIGNATURES_PATH" "$SIGNATURES_URL"
 fi
 
+# Download the category taxonomy the signatures refer to
+CATEGORIES_URL="https://raw.githubusercontent.com/Elite-Security-Systems/radar/$VERSION/data/categories.json"
+CATEGORIES_PATH="$SIGNATURES_DIR/categories.json"
+
+echo "Downloading category taxonomy to $CATEGORIES_PATH"
+
+if command -v curl > /dev/null; then
+    curl -L "$CATEGORIES_URL" -o "$CATEGORIES_PATH"
+elif command -v wget > /dev/null; then
+    wget -O "$CATEGORIES_PATH" "$CATEGORIES_URL"
+fi
+
+# Download the misconfiguration rules
+RULES_URL="https://raw.githubusercontent.com/Elite-Security-Systems/radar/$VERSION/data/rules.json"
+RULES_PATH="$SIGNATURES_DIR/rules.json"
+
+echo "Downloading misconfiguration rules to $RULES_PATH"
+
+if command -v curl > /dev/null; then
+    curl -L "$RULES_URL" -o "$RULES_PATH"
+elif command -v wget > /dev/null; then
+    wget -O "$RULES_PATH" "$RULES_URL"
+fi
+
 echo "Installation completed successfully!"
 echo ""
 echo "To use RADAR, run:"